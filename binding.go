@@ -0,0 +1,149 @@
+package occam
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	buildBindingRoot  = "/platform/bindings"
+	launchBindingRoot = "/bindings"
+)
+
+// Binding is a service binding, as described by the Service Binding
+// Specification for Kubernetes, given to buildpacks at build time and to the
+// app at run time.
+type Binding struct {
+	Name     string
+	Type     string
+	Provider string
+	Secrets  map[string]string
+}
+
+func NewBinding(name, bindingType string) Binding {
+	return Binding{
+		Name: name,
+		Type: bindingType,
+	}
+}
+
+func (b Binding) WithProvider(provider string) Binding {
+	b.Provider = provider
+	return b
+}
+
+// WithSecret adds an entry to the binding, written to a file named after the
+// key.
+func (b Binding) WithSecret(key, value string) Binding {
+	secrets := map[string]string{}
+	for k, v := range b.Secrets {
+		secrets[k] = v
+	}
+	secrets[key] = value
+
+	b.Secrets = secrets
+	return b
+}
+
+// WriteBindings writes the bindings to the given root directory, one
+// <root>/<name> directory per binding holding its type, provider and secrets.
+func WriteBindings(root string, bindings ...Binding) error {
+	names := map[string]bool{}
+	for _, binding := range bindings {
+		err := binding.validate()
+		if err != nil {
+			return err
+		}
+
+		if names[binding.Name] {
+			return fmt.Errorf("invalid binding %q: duplicate name", binding.Name)
+		}
+		names[binding.Name] = true
+	}
+
+	for _, binding := range bindings {
+		dir := filepath.Join(root, binding.Name)
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to write binding %q: %w", binding.Name, err)
+		}
+
+		files := map[string]string{"type": binding.Type}
+		if binding.Provider != "" {
+			files["provider"] = binding.Provider
+		}
+		for key, value := range binding.Secrets {
+			files[key] = value
+		}
+
+		var keys []string
+		for key := range files {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			err = os.WriteFile(filepath.Join(dir, key), []byte(files[key]), 0644)
+			if err != nil {
+				return fmt.Errorf("failed to write binding %q: %w", binding.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// materializeBindings writes the bindings to a new temporary directory and
+// returns it.
+func materializeBindings(bindings []Binding) (string, error) {
+	dir, err := os.MkdirTemp("", "bindings")
+	if err != nil {
+		return "", err
+	}
+
+	// The directory is mounted into containers that do not run as the
+	// current user.
+	err = os.Chmod(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = WriteBindings(dir, bindings...)
+	if err != nil {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up bindings: %s\n", err)
+		}
+
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func (b Binding) validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("invalid binding: missing name")
+	}
+
+	if strings.ContainsAny(b.Name, `/\`) || b.Name == "." || b.Name == ".." {
+		return fmt.Errorf("invalid binding %q: name must be a single path segment", b.Name)
+	}
+
+	if b.Type == "" {
+		return fmt.Errorf("invalid binding %q: missing type", b.Name)
+	}
+
+	for key := range b.Secrets {
+		switch {
+		case key == "type" || key == "provider":
+			return fmt.Errorf("invalid binding %q: secret %q is reserved", b.Name, key)
+		case key == "" || strings.ContainsAny(key, `/\`) || key == "." || key == "..":
+			return fmt.Errorf("invalid binding %q: secret %q must be a single path segment", b.Name, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package occam_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/occam"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBinding(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		root = t.TempDir()
+	})
+
+	context("WriteBindings", func() {
+		it("writes a directory per binding", func() {
+			err := occam.WriteBindings(root,
+				occam.NewBinding("some-ca-certificates", "ca-certificates").
+					WithProvider("some-provider").
+					WithSecret("ca.pem", "some-certificate"),
+				occam.NewBinding("some-maven", "maven").
+					WithSecret("settings.xml", "<settings/>"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			for path, content := range map[string]string{
+				"some-ca-certificates/type":     "ca-certificates",
+				"some-ca-certificates/provider": "some-provider",
+				"some-ca-certificates/ca.pem":   "some-certificate",
+				"some-maven/type":               "maven",
+				"some-maven/settings.xml":       "<settings/>",
+			} {
+				actual, err := os.ReadFile(filepath.Join(root, path))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(actual)).To(Equal(content), path)
+			}
+
+			Expect(filepath.Join(root, "some-maven", "provider")).NotTo(BeAnExistingFile())
+		})
+
+		it("does not modify the bindings it was derived from", func() {
+			binding := occam.NewBinding("some-binding", "some-type").WithSecret("some-key", "some-value")
+			_ = binding.WithSecret("other-key", "other-value")
+
+			Expect(binding.Secrets).To(Equal(map[string]string{"some-key": "some-value"}))
+		})
+
+		context("failure cases", func() {
+			context("when a binding has no type", func() {
+				it("returns an error", func() {
+					err := occam.WriteBindings(root, occam.NewBinding("some-binding", ""))
+					Expect(err).To(MatchError(`invalid binding "some-binding": missing type`))
+				})
+			})
+
+			context("when a binding name is not a single path segment", func() {
+				it("returns an error", func() {
+					err := occam.WriteBindings(root, occam.NewBinding("../some-binding", "some-type"))
+					Expect(err).To(MatchError(`invalid binding "../some-binding": name must be a single path segment`))
+				})
+			})
+
+			context("when two bindings have the same name", func() {
+				it("returns an error", func() {
+					err := occam.WriteBindings(root,
+						occam.NewBinding("some-binding", "some-type"),
+						occam.NewBinding("some-binding", "other-type"),
+					)
+					Expect(err).To(MatchError(`invalid binding "some-binding": duplicate name`))
+				})
+			})
+
+			context("when a secret uses a reserved key", func() {
+				it("returns an error", func() {
+					err := occam.WriteBindings(root, occam.NewBinding("some-binding", "some-type").WithSecret("type", "other-type"))
+					Expect(err).To(MatchError(`invalid binding "some-binding": secret "type" is reserved`))
+				})
+			})
+		})
+	})
+}
@@ -9,13 +9,44 @@ type Builder struct {
 }
 
 type BuilderInfo struct {
-	Description    string                      `json:"description"`
-	CreatedBy      BuilderInfoCreatedBy        `json:"created_by"`
-	Stack          BuilderInfoStack            `json:"stack"`
-	Lifecycle      BuilderInfoLifecycle        `json:"lifecycle"`
-	RunImages      []BuilderInfoRunImage       `json:"run_images"`
-	Buildpacks     []BuilderInfoBuildpack      `json:"buildpacks"`
-	DetectionOrder []BuilderInfoDetectionOrder `json:"detection_order"`
+	Description     string                      `json:"description"`
+	CreatedBy       BuilderInfoCreatedBy        `json:"created_by"`
+	Stack           BuilderInfoStack            `json:"stack"`
+	Lifecycle       BuilderInfoLifecycle        `json:"lifecycle"`
+	BuildImage      BuilderInfoBuildImage       `json:"build_image"`
+	RunImages       []BuilderInfoRunImage       `json:"run_images"`
+	Targets         []BuilderInfoTarget         `json:"targets"`
+	Buildpacks      []BuilderInfoBuildpack      `json:"buildpacks"`
+	DetectionOrder  []BuilderInfoDetectionOrder `json:"detection_order"`
+	Extensions      []BuilderInfoBuildpack      `json:"extensions"`
+	OrderExtensions []BuilderInfoDetectionOrder `json:"order_extensions"`
+}
+
+// RunImage returns the name of the run image of the builder, skipping the
+// mirrors configured locally.
+func (i BuilderInfo) RunImage() string {
+	for _, image := range i.RunImages {
+		if !image.UserConfigured {
+			return image.Name
+		}
+	}
+
+	return ""
+}
+
+// RunImageMirrors returns the names of the mirrors of the run image,
+// including those configured locally.
+func (i BuilderInfo) RunImageMirrors() []string {
+	runImage := i.RunImage()
+
+	var mirrors []string
+	for _, image := range i.RunImages {
+		if image.Name != runImage {
+			mirrors = append(mirrors, image.Name)
+		}
+	}
+
+	return mirrors
 }
 
 type BuilderInfoCreatedBy struct {
@@ -38,10 +69,30 @@ type BuilderInfoLifecycleAPIs struct {
 	Supported  []string `json:"supported"`
 }
 
-type BuilderInfoRunImage struct {
+type BuilderInfoBuildImage struct {
 	Name string `json:"name"`
 }
 
+// BuilderInfoRunImage is a run image of the builder. pack lists the mirrors
+// from the local pack config first, with UserConfigured set, followed by the
+// run image of the builder and its mirrors.
+type BuilderInfoRunImage struct {
+	Name           string `json:"name"`
+	UserConfigured bool   `json:"user_configured,omitempty"`
+}
+
+type BuilderInfoTarget struct {
+	OS          string                    `json:"os"`
+	Arch        string                    `json:"arch"`
+	ArchVariant string                    `json:"variant,omitempty"`
+	Distros     []BuilderInfoTargetDistro `json:"distros,omitempty"`
+}
+
+type BuilderInfoTargetDistro struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
 type BuilderInfoBuildpack struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
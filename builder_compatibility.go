@@ -0,0 +1,214 @@
+package occam
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BuilderCompatibilityError collects every incompatibility found between a
+// packaged buildpack and a builder. The individual errors are one of
+// UnsupportedBuildpackAPIError, StackMismatchError, TargetMismatchError or
+// MissingOrderDependencyError, and can be matched with errors.As.
+type BuilderCompatibilityError struct {
+	Errors []error
+}
+
+func (e BuilderCompatibilityError) Error() string {
+	var messages []string
+	for _, err := range e.Errors {
+		messages = append(messages, fmt.Sprintf("  - %s", err))
+	}
+
+	return fmt.Sprintf("buildpack is incompatible with builder:\n%s", strings.Join(messages, "\n"))
+}
+
+func (e BuilderCompatibilityError) Unwrap() []error {
+	return e.Errors
+}
+
+type UnsupportedBuildpackAPIError struct {
+	BuildpackID      string
+	BuildpackVersion string
+	API              string
+	Supported        []string
+}
+
+func (e UnsupportedBuildpackAPIError) Error() string {
+	return fmt.Sprintf("buildpack %s@%s uses buildpack API %s, which the builder lifecycle does not support (supported: %s)",
+		e.BuildpackID, e.BuildpackVersion, e.API, strings.Join(e.Supported, ", "))
+}
+
+type StackMismatchError struct {
+	BuildpackID      string
+	BuildpackVersion string
+	Stacks           []string
+	BuilderStack     string
+}
+
+func (e StackMismatchError) Error() string {
+	return fmt.Sprintf("buildpack %s@%s does not support stack %s (supported: %s)",
+		e.BuildpackID, e.BuildpackVersion, e.BuilderStack, strings.Join(e.Stacks, ", "))
+}
+
+type TargetMismatchError struct {
+	BuildpackID      string
+	BuildpackVersion string
+	Targets          []string
+	Target           string
+}
+
+func (e TargetMismatchError) Error() string {
+	return fmt.Sprintf("buildpack %s@%s does not support target %s (supported: %s)",
+		e.BuildpackID, e.BuildpackVersion, e.Target, strings.Join(e.Targets, ", "))
+}
+
+type MissingOrderDependencyError struct {
+	BuildpackID       string
+	BuildpackVersion  string
+	DependencyID      string
+	DependencyVersion string
+	Optional          bool
+}
+
+func (e MissingOrderDependencyError) Error() string {
+	return fmt.Sprintf("buildpack %s@%s requires %s@%s in its order, which is neither in the package nor on the builder",
+		e.BuildpackID, e.BuildpackVersion, e.DependencyID, e.DependencyVersion)
+}
+
+// BuilderCompatibilityCheck reports incompatibilities between a packaged
+// buildpack and a builder before running a slow `pack build`.
+type BuilderCompatibilityCheck struct {
+	target string
+}
+
+func NewBuilderCompatibilityCheck() BuilderCompatibilityCheck {
+	return BuilderCompatibilityCheck{}
+}
+
+// WithTarget sets the os/arch target the build will run on. Buildpacks that
+// declare targets are checked against it instead of the targets of the
+// builder.
+func (c BuilderCompatibilityCheck) WithTarget(target string) BuilderCompatibilityCheck {
+	c.target = target
+	return c
+}
+
+// Execute checks the buildpack at the given path, either a .cnb file or a
+// buildpack directory, against the builder. It returns a
+// BuilderCompatibilityError when any incompatibility is found.
+func (c BuilderCompatibilityCheck) Execute(buildpackPath string, builder Builder) error {
+	pkg, err := ReadBuildpackPackage(buildpackPath)
+	if err != nil {
+		return err
+	}
+
+	return c.Check(pkg, builder)
+}
+
+// Check is like Execute for a buildpack package that has already been read.
+func (c BuilderCompatibilityCheck) Check(pkg BuildpackPackage, builder Builder) error {
+	info := builder.LocalInfo
+	if info.Lifecycle.Version == "" && len(info.Buildpacks) == 0 {
+		info = builder.RemoteInfo
+	}
+
+	supportedAPIs := append(append([]string{}, info.Lifecycle.BuildpackAPIs.Supported...), info.Lifecycle.BuildpackAPIs.Deprecated...)
+
+	// Without an explicit target, the buildpack must support every target
+	// the builder was created for.
+	var builderTargets []string
+	if c.target != "" {
+		builderTargets = []string{c.target}
+	} else {
+		for _, target := range info.Targets {
+			builderTargets = append(builderTargets, fmt.Sprintf("%s/%s", target.OS, target.Arch))
+		}
+	}
+
+	var errs []error
+	for _, buildpack := range pkg.Buildpacks {
+		if buildpack.API != "" && len(supportedAPIs) > 0 && !slices.Contains(supportedAPIs, buildpack.API) {
+			errs = append(errs, UnsupportedBuildpackAPIError{
+				BuildpackID:      buildpack.ID,
+				BuildpackVersion: buildpack.Version,
+				API:              buildpack.API,
+				Supported:        supportedAPIs,
+			})
+		}
+
+		if len(buildpack.Stacks) > 0 && info.Stack.ID != "" &&
+			!slices.Contains(buildpack.Stacks, info.Stack.ID) && !slices.Contains(buildpack.Stacks, "*") {
+			errs = append(errs, StackMismatchError{
+				BuildpackID:      buildpack.ID,
+				BuildpackVersion: buildpack.Version,
+				Stacks:           buildpack.Stacks,
+				BuilderStack:     info.Stack.ID,
+			})
+		}
+
+		if len(buildpack.Targets) > 0 {
+			var targets []string
+			for _, target := range buildpack.Targets {
+				targets = append(targets, fmt.Sprintf("%s/%s", target.OS, target.Arch))
+			}
+
+			for _, target := range builderTargets {
+				if !slices.ContainsFunc(targets, func(t string) bool { return targetMatches(t, target) }) {
+					errs = append(errs, TargetMismatchError{
+						BuildpackID:      buildpack.ID,
+						BuildpackVersion: buildpack.Version,
+						Targets:          targets,
+						Target:           target,
+					})
+				}
+			}
+		}
+
+		for _, order := range buildpack.Order {
+			for _, group := range order.Group {
+				if _, err := pkg.Buildpack(group.ID, group.Version); err == nil {
+					continue
+				}
+
+				if builderHasBuildpack(info, group.ID, group.Version) {
+					continue
+				}
+
+				errs = append(errs, MissingOrderDependencyError{
+					BuildpackID:       buildpack.ID,
+					BuildpackVersion:  buildpack.Version,
+					DependencyID:      group.ID,
+					DependencyVersion: group.Version,
+					Optional:          group.Optional,
+				})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return BuilderCompatibilityError{Errors: errs}
+	}
+
+	return nil
+}
+
+// targetMatches reports whether two os/arch targets match. An empty os or
+// arch on either side, as in a target that only sets the os, matches any.
+func targetMatches(a, b string) bool {
+	aOS, aArch, _ := strings.Cut(a, "/")
+	bOS, bArch, _ := strings.Cut(b, "/")
+
+	return (aOS == "" || bOS == "" || aOS == bOS) &&
+		(aArch == "" || bArch == "" || aArch == bArch)
+}
+
+func builderHasBuildpack(info BuilderInfo, id, version string) bool {
+	for _, buildpack := range info.Buildpacks {
+		if buildpack.ID == id && (version == "" || buildpack.Version == version) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,203 @@
+package occam_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/occam"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuilderCompatibility(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cnbPath string
+		builder occam.Builder
+		check   occam.BuilderCompatibilityCheck
+	)
+
+	it.Before(func() {
+		cnbPath = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+		Expect(writeBuildpackPackage(cnbPath, map[string]string{
+			"io.buildpacks.buildpackage.metadata": `{"id": "some-org/some-composite", "version": "1.0.0"}`,
+			"io.buildpacks.buildpack.layers": `{
+				"some-org/some-composite": {
+					"1.0.0": {
+						"api": "0.8",
+						"order": [{"group": [
+							{"id": "some-org/some-component", "version": "2.0.0"},
+							{"id": "some-org/builder-component", "version": "3.0.0"}
+						]}]
+					}
+				},
+				"some-org/some-component": {
+					"2.0.0": {
+						"api": "0.9",
+						"stacks": [{"id": "some-stack"}],
+						"targets": [{"os": "linux", "arch": "amd64"}]
+					}
+				}
+			}`,
+		})).To(Succeed())
+
+		builder = occam.Builder{
+			LocalInfo: occam.BuilderInfo{
+				Stack: occam.BuilderInfoStack{ID: "some-stack"},
+				Lifecycle: occam.BuilderInfoLifecycle{
+					Version: "0.20.0",
+					BuildpackAPIs: occam.BuilderInfoLifecycleAPIs{
+						Deprecated: []string{"0.7"},
+						Supported:  []string{"0.8", "0.9"},
+					},
+				},
+				Buildpacks: []occam.BuilderInfoBuildpack{
+					{ID: "some-org/builder-component", Version: "3.0.0"},
+				},
+			},
+		}
+
+		check = occam.NewBuilderCompatibilityCheck().WithTarget("linux/amd64")
+	})
+
+	it("succeeds when the buildpack is compatible", func() {
+		Expect(check.Execute(cnbPath, builder)).To(Succeed())
+	})
+
+	it("falls back to the remote info when there is no local info", func() {
+		builder.RemoteInfo = builder.LocalInfo
+		builder.LocalInfo = occam.BuilderInfo{}
+
+		Expect(check.Execute(cnbPath, builder)).To(Succeed())
+	})
+
+	context("when no target is given", func() {
+		it.Before(func() {
+			check = occam.NewBuilderCompatibilityCheck()
+			builder.LocalInfo.Targets = []occam.BuilderInfoTarget{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "linux", Arch: "arm64"},
+			}
+		})
+
+		it("checks the buildpack against every target of the builder", func() {
+			err := check.Execute(cnbPath, builder)
+			Expect(err).To(MatchError(occam.BuilderCompatibilityError{
+				Errors: []error{
+					occam.TargetMismatchError{
+						BuildpackID:      "some-org/some-component",
+						BuildpackVersion: "2.0.0",
+						Targets:          []string{"linux/amd64"},
+						Target:           "linux/arm64",
+					},
+				},
+			}))
+		})
+	})
+
+	context("when a target leaves the os or arch unset", func() {
+		it.Before(func() {
+			check = occam.NewBuilderCompatibilityCheck()
+			builder.LocalInfo.Targets = []occam.BuilderInfoTarget{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "linux"},
+			}
+		})
+
+		it("treats the unset os or arch as any", func() {
+			Expect(check.Execute(cnbPath, builder)).To(Succeed())
+
+			Expect(check.WithTarget("/amd64").Execute(cnbPath, builder)).To(Succeed())
+			Expect(check.WithTarget("windows/amd64").Execute(cnbPath, builder)).To(MatchError(ContainSubstring("does not support target windows/amd64")))
+		})
+	})
+
+	context("when the buildpack is incompatible", func() {
+		it.Before(func() {
+			builder.LocalInfo.Stack.ID = "other-stack"
+			builder.LocalInfo.Lifecycle.BuildpackAPIs.Supported = []string{"0.8"}
+			builder.LocalInfo.Buildpacks = nil
+
+			check = check.WithTarget("linux/arm64")
+		})
+
+		it("reports every incompatibility", func() {
+			err := check.Execute(cnbPath, builder)
+			Expect(err).To(MatchError(occam.BuilderCompatibilityError{
+				Errors: []error{
+					occam.UnsupportedBuildpackAPIError{
+						BuildpackID:      "some-org/some-component",
+						BuildpackVersion: "2.0.0",
+						API:              "0.9",
+						Supported:        []string{"0.8", "0.7"},
+					},
+					occam.StackMismatchError{
+						BuildpackID:      "some-org/some-component",
+						BuildpackVersion: "2.0.0",
+						Stacks:           []string{"some-stack"},
+						BuilderStack:     "other-stack",
+					},
+					occam.TargetMismatchError{
+						BuildpackID:      "some-org/some-component",
+						BuildpackVersion: "2.0.0",
+						Targets:          []string{"linux/amd64"},
+						Target:           "linux/arm64",
+					},
+					occam.MissingOrderDependencyError{
+						BuildpackID:       "some-org/some-composite",
+						BuildpackVersion:  "1.0.0",
+						DependencyID:      "some-org/builder-component",
+						DependencyVersion: "3.0.0",
+					},
+				},
+			}))
+
+			Expect(err.Error()).To(ContainSubstring("buildpack some-org/some-component@2.0.0 does not support stack other-stack (supported: some-stack)"))
+
+			var apiErr occam.UnsupportedBuildpackAPIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.API).To(Equal("0.9"))
+		})
+	})
+
+	context("when checking a buildpack directory", func() {
+		it("checks the buildpack.toml", func() {
+			dir := t.TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(`
+api = "0.6"
+
+[buildpack]
+  id = "some-org/some-buildpack"
+  version = "4.0.0"
+
+[[stacks]]
+  id = "*"
+`), 0600)).To(Succeed())
+
+			err := check.Execute(dir, builder)
+			Expect(err).To(MatchError(occam.BuilderCompatibilityError{
+				Errors: []error{
+					occam.UnsupportedBuildpackAPIError{
+						BuildpackID:      "some-org/some-buildpack",
+						BuildpackVersion: "4.0.0",
+						API:              "0.6",
+						Supported:        []string{"0.8", "0.9", "0.7"},
+					},
+				},
+			}))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the buildpack cannot be read", func() {
+			it("returns an error", func() {
+				err := check.Execute("/no/such/path", builder)
+				Expect(err).To(MatchError(ContainSubstring("failed to read buildpack package")))
+			})
+		})
+	})
+}
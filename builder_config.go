@@ -0,0 +1,158 @@
+package occam
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+// BuilderConfig describes a builder to be created with `pack builder create`.
+// It is encoded into a builder.toml file.
+type BuilderConfig struct {
+	Description      string
+	StackID          string
+	BuildImage       string
+	RunImage         string
+	RunImageMirrors  []string
+	LifecycleVersion string
+	Buildpacks       []BuilderConfigBuildpack
+	Extensions       []BuilderConfigBuildpack
+	Order            []BuilderConfigOrder
+	OrderExtensions  []BuilderConfigOrder
+}
+
+// BuilderConfigBuildpack identifies a buildpack or extension to include in
+// the builder. A URI is passed to pack as-is, and may be anything pack
+// understands (a path, a docker:// or urn:cnb:registry: reference). A Ref is
+// first resolved through the BuildpackStore given to PackBuilderCreate.
+type BuilderConfigBuildpack struct {
+	URI string
+	Ref string
+}
+
+type BuilderConfigOrder struct {
+	Group []BuilderConfigOrderGroup
+}
+
+type BuilderConfigOrderGroup struct {
+	ID       string
+	Version  string
+	Optional bool
+}
+
+type builderToml struct {
+	Description     string               `toml:"description,omitempty"`
+	Buildpacks      []builderTomlModule  `toml:"buildpacks,omitempty"`
+	Extensions      []builderTomlModule  `toml:"extensions,omitempty"`
+	Order           []builderTomlOrder   `toml:"order,omitempty"`
+	OrderExtensions []builderTomlOrder   `toml:"order-extensions,omitempty"`
+	Stack           builderTomlStack     `toml:"stack,omitempty"`
+	Build           builderTomlBuild     `toml:"build,omitempty"`
+	Run             builderTomlRun       `toml:"run,omitempty"`
+	Lifecycle       builderTomlLifecycle `toml:"lifecycle,omitempty"`
+}
+
+type builderTomlModule struct {
+	URI string `toml:"uri"`
+}
+
+type builderTomlOrder struct {
+	Group []builderTomlOrderGroup `toml:"group"`
+}
+
+type builderTomlOrderGroup struct {
+	ID       string `toml:"id"`
+	Version  string `toml:"version,omitempty"`
+	Optional bool   `toml:"optional,omitempty"`
+}
+
+type builderTomlStack struct {
+	ID              string   `toml:"id,omitempty"`
+	BuildImage      string   `toml:"build-image,omitempty"`
+	RunImage        string   `toml:"run-image,omitempty"`
+	RunImageMirrors []string `toml:"run-image-mirrors,omitempty"`
+}
+
+type builderTomlBuild struct {
+	Image string `toml:"image,omitempty"`
+}
+
+type builderTomlRun struct {
+	Images []builderTomlRunImage `toml:"images,omitempty"`
+}
+
+type builderTomlRunImage struct {
+	Image   string   `toml:"image"`
+	Mirrors []string `toml:"mirrors,omitempty"`
+}
+
+type builderTomlLifecycle struct {
+	Version string `toml:"version,omitempty"`
+}
+
+// EncodeBuilderConfig writes the config as a builder.toml. Buildpacks and
+// extensions must already have been resolved to URIs.
+func EncodeBuilderConfig(writer io.Writer, config BuilderConfig) error {
+	builder := builderToml{
+		Description: config.Description,
+		Build:       builderTomlBuild{Image: config.BuildImage},
+		Lifecycle:   builderTomlLifecycle{Version: config.LifecycleVersion},
+	}
+
+	if config.RunImage != "" {
+		builder.Run.Images = []builderTomlRunImage{{Image: config.RunImage, Mirrors: config.RunImageMirrors}}
+	}
+
+	// Older versions of pack and the lifecycle only understand the [stack]
+	// table, so it is written alongside [build] and [run] when a stack is
+	// given.
+	if config.StackID != "" {
+		builder.Stack = builderTomlStack{
+			ID:              config.StackID,
+			BuildImage:      config.BuildImage,
+			RunImage:        config.RunImage,
+			RunImageMirrors: config.RunImageMirrors,
+		}
+	}
+
+	for _, buildpack := range config.Buildpacks {
+		if buildpack.URI == "" {
+			return fmt.Errorf("failed to encode builder config: buildpack %q has not been resolved to a URI", buildpack.Ref)
+		}
+
+		builder.Buildpacks = append(builder.Buildpacks, builderTomlModule{URI: buildpack.URI})
+	}
+
+	for _, extension := range config.Extensions {
+		if extension.URI == "" {
+			return fmt.Errorf("failed to encode builder config: extension %q has not been resolved to a URI", extension.Ref)
+		}
+
+		builder.Extensions = append(builder.Extensions, builderTomlModule{URI: extension.URI})
+	}
+
+	builder.Order = encodeBuilderOrder(config.Order)
+	builder.OrderExtensions = encodeBuilderOrder(config.OrderExtensions)
+
+	err := toml.NewEncoder(writer).Encode(builder)
+	if err != nil {
+		return fmt.Errorf("failed to encode builder config: %w", err)
+	}
+
+	return nil
+}
+
+func encodeBuilderOrder(orders []BuilderConfigOrder) []builderTomlOrder {
+	var encoded []builderTomlOrder
+	for _, order := range orders {
+		var group []builderTomlOrderGroup
+		for _, entry := range order.Group {
+			group = append(group, builderTomlOrderGroup(entry))
+		}
+
+		encoded = append(encoded, builderTomlOrder{Group: group})
+	}
+
+	return encoded
+}
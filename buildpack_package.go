@@ -0,0 +1,323 @@
+package occam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+)
+
+// BuildpackPackage describes a packaged buildpack: the buildpack at its root
+// and every buildpack it contains, including itself.
+type BuildpackPackage struct {
+	ID         string
+	Version    string
+	Buildpacks []BuildpackPackageBuildpack
+}
+
+type BuildpackPackageBuildpack struct {
+	ID          string
+	Version     string
+	Name        string
+	Homepage    string
+	API         string
+	Stacks      []string
+	Targets     []BuildpackPackageTarget
+	Order       []BuildpackPackageOrder
+	LayerDiffID string
+}
+
+type BuildpackPackageTarget struct {
+	OS          string
+	Arch        string
+	ArchVariant string
+	Distros     []BuildpackPackageTargetDistro
+}
+
+type BuildpackPackageTargetDistro struct {
+	Name    string
+	Version string
+}
+
+type BuildpackPackageOrder struct {
+	Group []BuildpackPackageOrderGroup
+}
+
+type BuildpackPackageOrderGroup struct {
+	ID       string
+	Version  string
+	Optional bool
+}
+
+// Root returns the buildpack at the root of the package.
+func (p BuildpackPackage) Root() (BuildpackPackageBuildpack, error) {
+	return p.Buildpack(p.ID, p.Version)
+}
+
+// Buildpack returns the contained buildpack with the given ID and version.
+// An empty version matches any version.
+func (p BuildpackPackage) Buildpack(id, version string) (BuildpackPackageBuildpack, error) {
+	for _, buildpack := range p.Buildpacks {
+		if buildpack.ID == id && (version == "" || buildpack.Version == version) {
+			return buildpack, nil
+		}
+	}
+
+	return BuildpackPackageBuildpack{}, fmt.Errorf("no buildpack found for %s@%s", id, version)
+}
+
+// Dependencies returns the buildpacks included in the package other than the
+// one at its root.
+func (p BuildpackPackage) Dependencies() []BuildpackPackageBuildpack {
+	var dependencies []BuildpackPackageBuildpack
+	for _, buildpack := range p.Buildpacks {
+		if buildpack.ID == p.ID && buildpack.Version == p.Version {
+			continue
+		}
+
+		dependencies = append(dependencies, buildpack)
+	}
+
+	return dependencies
+}
+
+// ReadBuildpackPackage reads a packaged buildpack from a .cnb file produced by
+// `pack buildpack package --format file`, or from a buildpack directory
+// containing a buildpack.toml.
+func ReadBuildpackPackage(path string) (BuildpackPackage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to read buildpack package: %w", err)
+	}
+
+	if info.IsDir() {
+		return readBuildpackDirectory(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to read buildpack package: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	layoutDir, err := os.MkdirTemp("", "buildpack-package")
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(layoutDir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up %s: %s\n", layoutDir, err)
+		}
+	}()
+
+	err = vacation.NewArchive(file).Decompress(layoutDir)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to decompress buildpack package: %w", err)
+	}
+
+	layoutPath, err := layout.FromPath(layoutDir)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to read buildpack package layout: %w", err)
+	}
+
+	index, err := layoutPath.ImageIndex()
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to read buildpack package layout: %w", err)
+	}
+
+	image, err := firstImage(index)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to read buildpack package layout: %w", err)
+	}
+
+	return NewBuildpackPackageFromImage(image)
+}
+
+// firstImage returns the first image of an index, descending into nested
+// indexes such as those of multi-platform packages.
+func firstImage(index v1.ImageIndex) (v1.Image, error) {
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(manifest.Manifests) == 0 {
+		return nil, fmt.Errorf("image index is empty")
+	}
+
+	descriptor := manifest.Manifests[0]
+	if descriptor.MediaType.IsIndex() {
+		child, err := index.ImageIndex(descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		return firstImage(child)
+	}
+
+	return index.Image(descriptor.Digest)
+}
+
+// NewBuildpackPackageFromImage decodes the buildpackage labels of an image,
+// such as a buildpack image pulled from a registry.
+func NewBuildpackPackageFromImage(image v1.Image) (BuildpackPackage, error) {
+	config, err := image.ConfigFile()
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to read buildpack package config: %w", err)
+	}
+
+	return NewBuildpackPackageFromLabels(config.Config.Labels)
+}
+
+// NewBuildpackPackageFromLabels decodes the io.buildpacks.buildpackage.metadata
+// and io.buildpacks.buildpack.layers labels of a buildpack image.
+func NewBuildpackPackageFromLabels(labels map[string]string) (BuildpackPackage, error) {
+	for _, label := range []string{"io.buildpacks.buildpackage.metadata", "io.buildpacks.buildpack.layers"} {
+		if _, ok := labels[label]; !ok {
+			return BuildpackPackage{}, fmt.Errorf("image is not a buildpack package: missing %s label", label)
+		}
+	}
+
+	var metadata struct {
+		ID      string `json:"id"`
+		Version string `json:"version"`
+	}
+	err := json.Unmarshal([]byte(labels["io.buildpacks.buildpackage.metadata"]), &metadata)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to parse buildpackage metadata label: %w", err)
+	}
+
+	var layers map[string]map[string]struct {
+		API      string `json:"api"`
+		Name     string `json:"name"`
+		Homepage string `json:"homepage"`
+		Stacks   []struct {
+			ID string `json:"id"`
+		} `json:"stacks"`
+		Targets []struct {
+			OS          string `json:"os"`
+			Arch        string `json:"arch"`
+			ArchVariant string `json:"variant"`
+			Distros     []struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"distros"`
+		} `json:"targets"`
+		Order []struct {
+			Group []struct {
+				ID       string `json:"id"`
+				Version  string `json:"version"`
+				Optional bool   `json:"optional"`
+			} `json:"group"`
+		} `json:"order"`
+		LayerDiffID string `json:"layerDiffID"`
+	}
+	err = json.Unmarshal([]byte(labels["io.buildpacks.buildpack.layers"]), &layers)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to parse buildpack layers label: %w", err)
+	}
+
+	pkg := BuildpackPackage{
+		ID:      metadata.ID,
+		Version: metadata.Version,
+	}
+
+	for id, versions := range layers {
+		for version, layer := range versions {
+			buildpack := BuildpackPackageBuildpack{
+				ID:          id,
+				Version:     version,
+				Name:        layer.Name,
+				Homepage:    layer.Homepage,
+				API:         layer.API,
+				LayerDiffID: layer.LayerDiffID,
+			}
+
+			for _, stack := range layer.Stacks {
+				buildpack.Stacks = append(buildpack.Stacks, stack.ID)
+			}
+
+			for _, target := range layer.Targets {
+				t := BuildpackPackageTarget{
+					OS:          target.OS,
+					Arch:        target.Arch,
+					ArchVariant: target.ArchVariant,
+				}
+				for _, distro := range target.Distros {
+					t.Distros = append(t.Distros, BuildpackPackageTargetDistro(distro))
+				}
+				buildpack.Targets = append(buildpack.Targets, t)
+			}
+
+			for _, order := range layer.Order {
+				var group []BuildpackPackageOrderGroup
+				for _, entry := range order.Group {
+					group = append(group, BuildpackPackageOrderGroup(entry))
+				}
+				buildpack.Order = append(buildpack.Order, BuildpackPackageOrder{Group: group})
+			}
+
+			pkg.Buildpacks = append(pkg.Buildpacks, buildpack)
+		}
+	}
+
+	sort.Slice(pkg.Buildpacks, func(i, j int) bool {
+		if pkg.Buildpacks[i].ID != pkg.Buildpacks[j].ID {
+			return pkg.Buildpacks[i].ID < pkg.Buildpacks[j].ID
+		}
+
+		return pkg.Buildpacks[i].Version < pkg.Buildpacks[j].Version
+	})
+
+	return pkg, nil
+}
+
+func readBuildpackDirectory(path string) (BuildpackPackage, error) {
+	config, err := cargo.NewBuildpackParser().Parse(filepath.Join(path, "buildpack.toml"))
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to parse buildpack.toml: %w", err)
+	}
+
+	buildpack := BuildpackPackageBuildpack{
+		ID:       config.Buildpack.ID,
+		Version:  config.Buildpack.Version,
+		Name:     config.Buildpack.Name,
+		Homepage: config.Buildpack.Homepage,
+		API:      config.API,
+	}
+
+	for _, stack := range config.Stacks {
+		buildpack.Stacks = append(buildpack.Stacks, stack.ID)
+	}
+
+	for _, target := range config.Targets {
+		buildpack.Targets = append(buildpack.Targets, BuildpackPackageTarget{
+			OS:   target.OS,
+			Arch: target.Arch,
+		})
+	}
+
+	for _, order := range config.Order {
+		var group []BuildpackPackageOrderGroup
+		for _, entry := range order.Group {
+			group = append(group, BuildpackPackageOrderGroup(entry))
+		}
+		buildpack.Order = append(buildpack.Order, BuildpackPackageOrder{Group: group})
+	}
+
+	return BuildpackPackage{
+		ID:         config.Buildpack.ID,
+		Version:    config.Buildpack.Version,
+		Buildpacks: []BuildpackPackageBuildpack{buildpack},
+	}, nil
+}
@@ -0,0 +1,278 @@
+package occam_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/paketo-buildpacks/occam"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuildpackPackage(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cnbPath string
+	)
+
+	it.Before(func() {
+		cnbPath = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+		Expect(writeBuildpackPackage(cnbPath, map[string]string{
+			"io.buildpacks.buildpackage.metadata": `{"id": "some-org/some-composite", "version": "1.0.0", "stacks": [{"id": "*"}]}`,
+			"io.buildpacks.buildpack.layers": `{
+				"some-org/some-composite": {
+					"1.0.0": {
+						"api": "0.8",
+						"name": "Some Composite",
+						"order": [{"group": [{"id": "some-org/some-component", "version": "2.0.0", "optional": true}]}],
+						"layerDiffID": "sha256:composite"
+					}
+				},
+				"some-org/some-component": {
+					"2.0.0": {
+						"api": "0.10",
+						"homepage": "https://example.com",
+						"stacks": [{"id": "some-stack"}],
+						"targets": [{"os": "linux", "arch": "arm64", "variant": "v8", "distros": [{"name": "ubuntu", "version": "22.04"}]}],
+						"layerDiffID": "sha256:component"
+					}
+				}
+			}`,
+		})).To(Succeed())
+	})
+
+	context("ReadBuildpackPackage", func() {
+		it("reads a .cnb file", func() {
+			pkg, err := occam.ReadBuildpackPackage(cnbPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pkg).To(Equal(occam.BuildpackPackage{
+				ID:      "some-org/some-composite",
+				Version: "1.0.0",
+				Buildpacks: []occam.BuildpackPackageBuildpack{
+					{
+						ID:       "some-org/some-component",
+						Version:  "2.0.0",
+						API:      "0.10",
+						Homepage: "https://example.com",
+						Stacks:   []string{"some-stack"},
+						Targets: []occam.BuildpackPackageTarget{
+							{
+								OS:          "linux",
+								Arch:        "arm64",
+								ArchVariant: "v8",
+								Distros:     []occam.BuildpackPackageTargetDistro{{Name: "ubuntu", Version: "22.04"}},
+							},
+						},
+						LayerDiffID: "sha256:component",
+					},
+					{
+						ID:      "some-org/some-composite",
+						Version: "1.0.0",
+						API:     "0.8",
+						Name:    "Some Composite",
+						Order: []occam.BuildpackPackageOrder{
+							{Group: []occam.BuildpackPackageOrderGroup{{ID: "some-org/some-component", Version: "2.0.0", Optional: true}}},
+						},
+						LayerDiffID: "sha256:composite",
+					},
+				},
+			}))
+
+			root, err := pkg.Root()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(root.Name).To(Equal("Some Composite"))
+		})
+
+		it("reads a buildpack directory", func() {
+			dir := t.TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(`
+api = "0.7"
+
+[buildpack]
+  id = "some-org/some-buildpack"
+  version = "3.0.0"
+
+[[stacks]]
+  id = "some-stack"
+
+[[targets]]
+  os = "linux"
+  arch = "amd64"
+`), 0600)).To(Succeed())
+
+			pkg, err := occam.ReadBuildpackPackage(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pkg).To(Equal(occam.BuildpackPackage{
+				ID:      "some-org/some-buildpack",
+				Version: "3.0.0",
+				Buildpacks: []occam.BuildpackPackageBuildpack{
+					{
+						ID:      "some-org/some-buildpack",
+						Version: "3.0.0",
+						API:     "0.7",
+						Stacks:  []string{"some-stack"},
+						Targets: []occam.BuildpackPackageTarget{{OS: "linux", Arch: "amd64"}},
+					},
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when the path does not exist", func() {
+				it("returns an error", func() {
+					_, err := occam.ReadBuildpackPackage("/no/such/path")
+					Expect(err).To(MatchError(ContainSubstring("failed to read buildpack package")))
+				})
+			})
+
+			context("when the labels are malformed", func() {
+				it.Before(func() {
+					Expect(writeBuildpackPackage(cnbPath, map[string]string{
+						"io.buildpacks.buildpackage.metadata": "%%%",
+						"io.buildpacks.buildpack.layers":      "{}",
+					})).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := occam.ReadBuildpackPackage(cnbPath)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse buildpackage metadata label")))
+				})
+			})
+		})
+	})
+}
+
+// writeBuildpackPackage writes a .cnb file, an OCI layout in a tarball, for
+// an image with the given labels.
+func writeBuildpackPackage(path string, labels map[string]string) error {
+	image, err := mutate.Config(empty.Image, v1.Config{Labels: labels})
+	if err != nil {
+		return err
+	}
+
+	return writeImageLayout(path, image)
+}
+
+// writeBuildpackPackageLayer writes a .cnb file holding a single buildpack
+// layer with the given files under /cnb/buildpacks/<id>/<version>.
+func writeBuildpackPackageLayer(path, id, version string, files map[string]string) error {
+	buffer := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buffer)
+	for name, content := range files {
+		name = fmt.Sprintf("cnb/buildpacks/%s/%s/%s", strings.ReplaceAll(id, "/", "_"), version, name)
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			return err
+		}
+
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		return err
+	}
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buffer.Bytes())), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return err
+	}
+
+	image, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return err
+	}
+
+	image, err = mutate.Config(image, v1.Config{
+		Labels: map[string]string{
+			"io.buildpacks.buildpackage.metadata": fmt.Sprintf(`{"id": %q, "version": %q}`, id, version),
+			"io.buildpacks.buildpack.layers":      fmt.Sprintf(`{%q: {%q: {"api": "0.8", "layerDiffID": %q}}}`, id, version, diffID),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeImageLayout(path, image)
+}
+
+func writeImageLayout(path string, image v1.Image) error {
+	layoutDir, err := os.MkdirTemp("", "layout")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(layoutDir)
+
+	layoutPath, err := layout.Write(layoutDir, empty.Index)
+	if err != nil {
+		return err
+	}
+
+	err = layoutPath.AppendImage(image)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+	defer tw.Close()
+
+	return filepath.Walk(layoutDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(layoutDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+
+		err = tw.WriteHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
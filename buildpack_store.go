@@ -1,6 +1,7 @@
 package occam
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -47,7 +48,7 @@ type BuildpackStore struct {
 func NewBuildpackStore() BuildpackStore {
 	gitToken := os.Getenv("GIT_TOKEN")
 	cacheManager := freezer.NewCacheManager(filepath.Join(os.Getenv("HOME"), ".freezer-cache"))
-	releaseService := github.NewReleaseService(github.NewConfig("https://api.github.com", gitToken))
+	releaseService := NewGitHubReleaseService(github.NewConfig("https://api.github.com", gitToken))
 	packager := packagers.NewJam()
 	namer := freezer.NewNameGenerator()
 	extractor := NewRegistryBuildpackImageExtractor(NewDocker())
@@ -65,6 +66,8 @@ func NewBuildpackStore() BuildpackStore {
 			),
 			extractor:    extractor,
 			cacheManager: &cacheManager,
+			releases:     releaseService,
+			packager:     packager,
 		},
 	}
 }
@@ -74,6 +77,10 @@ func (bs BuildpackStore) WithLocalFetcher(fetcher LocalFetcher) BuildpackStore {
 	return bs
 }
 
+// WithRemoteFetcher sets the fetcher for github.com references. References
+// pinned by a lockfile are fetched through it too, asking for the pinned
+// version, unless it is a freezer.RemoteFetcher, which only downloads the
+// latest release and is replaced by one for the pinned release.
 func (bs BuildpackStore) WithRemoteFetcher(fetcher RemoteFetcher) BuildpackStore {
 	bs.Get.remote = fetcher
 	return bs
@@ -85,8 +92,10 @@ func (bs BuildpackStore) WithCacheManager(manager CacheManager) BuildpackStore {
 }
 
 func (bs BuildpackStore) WithPackager(packager freezer.Packager) BuildpackStore {
+	packager, _ = packagerWithContext(bs.Get.ctx, packager)
 	bs.Get.local = bs.Get.local.WithPackager(packager)
 	bs.Get.remote = bs.Get.remote.WithPackager(packager)
+	bs.Get.packager = packager
 	return bs
 }
 
@@ -98,7 +107,122 @@ func (bs BuildpackStore) WithTarget(target string) BuildpackStore {
 }
 
 func (bs BuildpackStore) WithRegistryBuildpackExtractor(extractor RegistryBuildpackToLocal) BuildpackStore {
-	bs.Get.extractor = extractor
+	bs.Get = bs.Get.withExtractor(extractor)
+	return bs
+}
+
+// WithContext stops resolving buildpacks when the context is done. The
+// packagers of this module, the docker commands of the
+// RegistryBuildpackImageExtractor and pack, when assembling composite
+// buildpacks, are killed. Other packagers and extractors are left as they are.
+func (bs BuildpackStore) WithContext(ctx context.Context) BuildpackStore {
+	bs.Get.ctx = ctx
+
+	if packager, ok := packagerWithContext(ctx, bs.Get.packager); ok {
+		bs = bs.WithPackager(packager)
+	}
+
+	bs.Get = bs.Get.withExtractor(bs.Get.extractor)
+	return bs
+}
+
+// packagerWithContext gives the context to the packagers of this module,
+// reporting whether the packager could be given it.
+func packagerWithContext(ctx context.Context, packager freezer.Packager) (freezer.Packager, bool) {
+	if ctx == nil {
+		return packager, false
+	}
+
+	switch p := packager.(type) {
+	case packagers.Jam:
+		return p.WithContext(ctx), true
+	case packagers.Libpak:
+		return p.WithContext(ctx), true
+	case packagers.LibpakTools:
+		return p.WithContext(ctx), true
+	case packagers.Native:
+		return p.WithContext(ctx), true
+	case packagers.Detecting:
+		return p.WithContext(ctx), true
+	default:
+		return packager, false
+	}
+}
+
+// withExtractor gives the context and the retry policy of the store to a
+// RegistryBuildpackImageExtractor, which retries extracting registry
+// buildpacks itself.
+func (g BuildpackStoreGet) withExtractor(extractor RegistryBuildpackToLocal) BuildpackStoreGet {
+	if e, ok := extractor.(RegistryBuildpackImageExtractor); ok {
+		if g.ctx != nil {
+			e.docker = e.docker.WithContext(g.ctx)
+		}
+
+		if g.retry.Attempts > 0 {
+			e.docker = e.docker.WithRetryPolicy(g.retry)
+		}
+
+		extractor = e
+	}
+
+	g.extractor = extractor
+	return g
+}
+
+// WithAirGapped puts the store into a strict offline mode. Local buildpack
+// directories are still packaged, but github.com and registry references are
+// only ever served from the cache. A reference that is not cached fails
+// immediately with a MissingBuildpacksError instead of attempting a network
+// call.
+func (bs BuildpackStore) WithAirGapped() BuildpackStore {
+	bs.Get.airGapped = true
+	return bs
+}
+
+// WithGitReleaseFetcher sets the release fetcher used to download the
+// specific github.com releases pinned by a lockfile. Unpinned references are
+// still fetched through the remote fetcher.
+func (bs BuildpackStore) WithGitReleaseFetcher(fetcher GitReleaseFetcher) BuildpackStore {
+	bs.Get.releases = fetcher
+	return bs
+}
+
+// WithLockfile resolves github.com and registry references to the versions
+// recorded in the lockfile at the given path. References that are not yet
+// locked are resolved as usual and then added to the lockfile, which is
+// created if it does not exist.
+func (bs BuildpackStore) WithLockfile(path string) BuildpackStore {
+	bs.Get.lockfile = path
+	bs.Get.frozen = false
+	return bs
+}
+
+// WithFrozenLockfile behaves like WithLockfile but never writes the lockfile:
+// a reference that is not locked is an error. Use it in CI to make sure the
+// suite runs against exactly the buildpacks that were locked.
+func (bs BuildpackStore) WithFrozenLockfile(path string) BuildpackStore {
+	bs.Get.lockfile = path
+	bs.Get.frozen = true
+	return bs
+}
+
+// WithRetryPolicy retries fetching github.com buildpacks, and extracting
+// registry buildpacks, when it fails with a transient error such as a 5xx
+// response or a TLS handshake timeout. Every attempt is reported in the final
+// error, a RetryError. Registry buildpacks are retried by the
+// RegistryBuildpackImageExtractor, which is given the policy; other
+// extractors are attempted once.
+func (bs BuildpackStore) WithRetryPolicy(policy RetryPolicy) BuildpackStore {
+	bs.Get.retry = policy
+	bs.Get = bs.Get.withExtractor(bs.Get.extractor)
+	return bs
+}
+
+// WithManifest seeds the cache from the manifest file at the given path
+// before any buildpack is resolved. See BuildpackStoreManifest for the file
+// format.
+func (bs BuildpackStore) WithManifest(path string) BuildpackStore {
+	bs.Get.manifest = path
 	return bs
 }
 
@@ -107,18 +231,49 @@ type BuildpackStoreGet struct {
 	local        LocalFetcher
 	remote       RemoteFetcher
 	extractor    RegistryBuildpackToLocal
+	releases     GitReleaseFetcher
+	packager     freezer.Packager
+
+	airGapped bool
+	manifest  string
+	lockfile  string
+	frozen    bool
 
 	offline bool
 	version string
+	pinned  bool
 
 	platform string
 	arch     string
+
+	retry RetryPolicy
+	ctx   context.Context
 }
 
 func (g BuildpackStoreGet) Execute(url string) (string, error) {
+	path, _, err := g.execute(url)
+	return path, err
+}
+
+// Result resolves the reference like Execute and describes the packaged
+// buildpack it resolved to: its digest, ID, version and whether its
+// dependencies are included. The Logs of buildpacks served from the cache
+// are empty.
+func (g BuildpackStoreGet) Result(url string) (packagers.Result, error) {
+	path, _, err := g.execute(url)
+	if err != nil {
+		return packagers.Result{}, err
+	}
+
+	return packagers.ReadResult(path)
+}
+
+// execute resolves the reference like Execute and also reports the version of
+// the buildpack that was resolved.
+func (g BuildpackStoreGet) execute(url string) (string, string, error) {
 	err := g.cacheManager.Open()
 	if err != nil {
-		return "", fmt.Errorf("failed to open cacheManager: %s", err)
+		return "", "", fmt.Errorf("failed to open cacheManager: %s", err)
 	}
 	defer func() {
 		if err := g.cacheManager.Close(); err != nil {
@@ -126,48 +281,436 @@ func (g BuildpackStoreGet) Execute(url string) (string, error) {
 		}
 	}()
 
-	info, err := os.Stat(url)
+	if g.manifest != "" {
+		err = g.seed(g.manifest)
+		if err != nil {
+			return "", "", err
+		}
+	}
 
-	switch {
-	case err == nil && info.IsDir():
+	info, err := os.Stat(url)
+	if err == nil && info.IsDir() {
 		buildpack := freezer.NewLocalBuildpack(url, filepath.Base(url)).
 			WithOffline(g.offline).
 			WithVersion(g.version)
 
-		return g.local.Get(buildpack)
-	case strings.HasPrefix(url, "github.com"):
-		request := strings.SplitN(url, "/", 3)
-		if len(request) < 3 {
-			return "", fmt.Errorf("error incomplete github.com url: %q", url)
+		path, err := g.local.Get(buildpack)
+		return path, g.version, err
+	}
+
+	if g.lockfile != "" {
+		return g.resolveLocked(url)
+	}
+
+	if g.pinned && strings.HasPrefix(url, "github.com") {
+		path, version, _, err := g.resolveRelease(url, g.version)
+		return path, version, err
+	}
+
+	path, version, err := g.resolve(url)
+	if err != nil {
+		return "", "", err
+	}
+
+	if version == "" {
+		version, err = g.cachedVersion(url)
+		if err != nil {
+			return "", "", err
 		}
+	}
+
+	return path, version, nil
+}
 
-		if g.platform == "" || g.arch == "" {
-			g.platform = "linux"
-			g.arch = "amd64"
+func (g BuildpackStoreGet) cachedVersion(url string) (string, error) {
+	key, _, err := g.cacheLocation(url, g.offline)
+	if err != nil {
+		return "", err
+	}
+
+	entry, _, err := g.cacheManager.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cache entry %q: %w", key, err)
+	}
+
+	return entry.Version, nil
+}
+
+// resolve fetches a github.com or registry reference. The returned version
+// is empty when it is not known without consulting the cache.
+func (g BuildpackStoreGet) resolve(url string) (string, string, error) {
+	if g.airGapped {
+		entry, found, err := g.lookup(url)
+		if err != nil {
+			return "", "", err
 		}
 
-		buildpack := freezer.NewRemoteBuildpack(request[1], request[2], g.platform, g.arch).
+		if !found {
+			return "", "", MissingBuildpacksError{Missing: []string{g.describe(url)}}
+		}
+
+		return entry.URI, entry.Version, nil
+	}
+
+	if strings.HasPrefix(url, "github.com") {
+		org, repo, err := parseGithubURL(url)
+		if err != nil {
+			return "", "", err
+		}
+
+		platform, arch := g.target()
+		buildpack := freezer.NewRemoteBuildpack(org, repo, platform, arch).
 			WithOffline(g.offline).
 			WithVersion(g.version)
 
-		return g.remote.Get(buildpack)
-	default:
-		tmpDir, err := os.MkdirTemp("", filepath.Base(url))
+		var path string
+		err = g.retry.do(g.ctx, func() error {
+			path, err = g.remote.Get(buildpack)
+			return err
+		})
+		return path, "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", filepath.Base(url))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	buildpackRootPath, version, err := g.extractor.Extract(url, tmpDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create local buildpack from registry image: %w", err)
+	}
+
+	buildpack := freezer.NewLocalBuildpack(buildpackRootPath, filepath.Base(url)).
+		WithOffline(g.offline).
+		WithVersion(version)
+
+	path, err := g.local.Get(buildpack)
+	return path, version, err
+}
+
+func (g BuildpackStoreGet) resolveLocked(url string) (string, string, error) {
+	if !strings.HasPrefix(url, "github.com") {
+		// A registry tag can move, and the buildpack in the image is packaged
+		// locally, so there is nothing reproducible to lock. An image pinned by
+		// digest always resolves to the same buildpack, and needs no lock.
+		if !strings.Contains(url, "@sha256:") {
+			return "", "", fmt.Errorf("failed to resolve %s: registry references cannot be locked, pin the image by digest (%s@sha256:<digest>) instead", url, url)
+		}
+
+		path, version, err := g.resolve(url)
 		if err != nil {
-			return "", fmt.Errorf("failed to create temp dir: %w", err)
+			return "", "", err
 		}
 
-		buildpackRootPath, version, err := g.extractor.Extract(url, tmpDir)
+		if version == "" {
+			version, err = g.cachedVersion(url)
+			if err != nil {
+				return "", "", err
+			}
+		}
+
+		return path, version, nil
+	}
+
+	lockfile, err := ParseBuildpackStoreLockfile(g.lockfile)
+	if err != nil {
+		return "", "", err
+	}
+
+	platform, arch := g.target()
+	target := fmt.Sprintf("%s/%s", platform, arch)
+
+	lock, locked := lockfile.Find(url, target, g.offline)
+	if !locked && g.frozen {
+		return "", "", fmt.Errorf("failed to resolve %s: not found in frozen lockfile %s", url, g.lockfile)
+	}
+
+	if locked {
+		g.version = lock.Version
+	}
+
+	path, version, digest, err := g.resolveRelease(url, lock.Version)
+	if err != nil {
+		return "", "", err
+	}
+
+	if locked {
+		if version != lock.Version {
+			return "", "", fmt.Errorf("failed to resolve %s: resolved version %s does not match locked version %s", url, version, lock.Version)
+		}
+
+		if lock.Digest != "" && digest != "" && digest != lock.Digest {
+			return "", "", fmt.Errorf("failed to resolve %s: digest %s does not match locked digest %s", url, digest, lock.Digest)
+		}
+
+		return path, version, nil
+	}
+
+	err = addToLockfile(g.lockfile, BuildpackStoreLock{
+		URL:     url,
+		Version: version,
+		Digest:  digest,
+		Target:  target,
+		Offline: g.offline,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return path, version, nil
+}
+
+// resolveRelease fetches a github.com reference at the pinned version, or at
+// its latest release, through the git release fetcher. It also returns the
+// digest of the release asset, or source tarball, that the buildpack was
+// downloaded or packaged from. Unlike the digest of a packaged buildpack, it
+// is the same on every machine. The source is hashed as it is downloaded, and
+// the digest is empty for a buildpack packaged from a source tarball that was
+// cached without recording it.
+func (g BuildpackStoreGet) resolveRelease(url, pinned string) (string, string, string, error) {
+	org, repo, err := parseGithubURL(url)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if g.airGapped {
+		entry, found, err := g.lookup(url)
 		if err != nil {
-			return "", fmt.Errorf("failed to create local buildpack from registry image: %w", err)
+			return "", "", "", err
 		}
 
-		buildpack := freezer.NewLocalBuildpack(buildpackRootPath, filepath.Base(url)).
-			WithOffline(g.offline).
-			WithVersion(version)
+		if !found {
+			return "", "", "", MissingBuildpacksError{Missing: []string{g.describe(url)}}
+		}
 
-		return g.local.Get(buildpack)
+		digest, err := readSourceDigest(entry.URI)
+		if err != nil {
+			return "", "", "", err
+		}
+
+		return entry.URI, entry.Version, digest, nil
+	}
+
+	if g.releases == nil {
+		return "", "", "", fmt.Errorf("failed to fetch %s: no git release fetcher configured", url)
+	}
+
+	var release github.Release
+	err = g.retry.do(g.ctx, func() error {
+		if pinned == "" {
+			release, err = g.releases.Get(org, repo)
+		} else {
+			release, err = g.releases.GetTag(org, repo, pinned)
+		}
+		return err
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+
+	version := strings.TrimPrefix(release.TagName, "v")
+
+	platform, arch := g.target()
+	buildpack := freezer.NewRemoteBuildpack(org, repo, platform, arch).
+		WithOffline(g.offline).
+		WithVersion(version)
+
+	digest := &sourceDigest{}
+	remote := g.pinnedRemote(release, digest)
+
+	var path string
+	err = g.retry.do(g.ctx, func() error {
+		path, err = remote.Get(buildpack)
+		return err
+	})
+	if err != nil {
+		digest.close()
+		return "", "", "", err
+	}
+
+	sum, err := digest.finish()
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to compute digest of %s: %w", url, err)
+	}
+
+	if sum == "" {
+		sum, err = readSourceDigest(path)
+		if err != nil {
+			return "", "", "", err
+		}
+
+		if sum != "" {
+			return path, version, sum, nil
+		}
+
+		// A release asset is cached as it was downloaded, so its digest is
+		// that of the cached artifact. A buildpack packaged from the source
+		// tarball that was cached without recording its digest has none.
+		if len(release.Assets) == 0 || g.offline {
+			return path, version, "", nil
+		}
+
+		sum, err = fileDigest(path)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to compute digest of %s: %w", url, err)
+		}
+	}
+
+	err = writeSourceDigest(path, sum)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return path, version, sum, nil
+}
+
+// pinnedRemote returns the remote fetcher that downloads the given release,
+// hashing the download into the digest. The freezer remote fetcher always
+// downloads the latest release, so it is built again from the cache manager,
+// release fetcher and packager of the store around the pinned release. Any
+// other fetcher, such as a fake, is used as it is and asked for the version
+// of the release.
+func (g BuildpackStoreGet) pinnedRemote(release github.Release, digest *sourceDigest) RemoteFetcher {
+	if _, ok := g.remote.(freezer.RemoteFetcher); !ok {
+		return g.remote
+	}
+
+	return freezer.NewRemoteFetcher(g.cacheManager, pinnedReleaseFetcher{
+		GitReleaseFetcher: g.releases,
+		release:           release,
+		digest:            digest,
+	}, g.packager)
+}
+
+// Verify checks that every given reference can be served without network
+// access. Local buildpack directories always pass; github.com and registry
+// references must already be cached (or seeded by the manifest). All missing
+// references are reported together in a MissingBuildpacksError.
+func (g BuildpackStoreGet) Verify(urls ...string) error {
+	err := g.cacheManager.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open cacheManager: %s", err)
+	}
+	defer func() {
+		if err := g.cacheManager.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close cache manager: %s\n", err)
+		}
+	}()
+
+	if g.manifest != "" {
+		err = g.seed(g.manifest)
+		if err != nil {
+			return err
+		}
+	}
+
+	var missing []string
+	for _, url := range urls {
+		if info, err := os.Stat(url); err == nil && info.IsDir() {
+			continue
+		}
+
+		_, found, err := g.lookup(url)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			missing = append(missing, g.describe(url))
+		}
+	}
+
+	if len(missing) > 0 {
+		return MissingBuildpacksError{Missing: missing}
+	}
+
+	return nil
+}
+
+// lookup finds the cached artifact for the given reference. When a version
+// has been requested, a cached artifact of any other version is treated as
+// missing.
+func (g BuildpackStoreGet) lookup(url string) (freezer.CacheEntry, bool, error) {
+	key, _, err := g.cacheLocation(url, g.offline)
+	if err != nil {
+		return freezer.CacheEntry{}, false, err
+	}
+
+	entry, found, err := g.cacheManager.Get(key)
+	if err != nil {
+		return freezer.CacheEntry{}, false, fmt.Errorf("failed to read cache entry %q: %w", key, err)
+	}
+
+	if !found || (g.version != "" && entry.Version != g.version) {
+		return freezer.CacheEntry{}, false, nil
+	}
+
+	return entry, true, nil
+}
+
+func (g BuildpackStoreGet) describe(url string) string {
+	key, _, err := g.cacheLocation(url, g.offline)
+	if err != nil {
+		key = "unknown"
+	}
+
+	if g.version != "" {
+		return fmt.Sprintf("%s@%s (cache key %q)", url, g.version, key)
+	}
+
+	return fmt.Sprintf("%s (cache key %q)", url, key)
+}
+
+// cacheLocation mirrors the keys and directories used by the freezer fetchers
+// so that artifacts can be found or placed without going through them.
+func (g BuildpackStoreGet) cacheLocation(url string, offline bool) (string, string, error) {
+	var key, dir string
+	if strings.HasPrefix(url, "github.com") {
+		org, repo, err := parseGithubURL(url)
+		if err != nil {
+			return "", "", err
+		}
+
+		platform, arch := g.target()
+		buildpack := freezer.NewRemoteBuildpack(org, repo, platform, arch)
+		key = buildpack.UncachedKey
+		if offline {
+			key = buildpack.CachedKey
+		}
+		dir = filepath.Join(g.cacheManager.Dir(), org, repo, platform, arch)
+	} else {
+		name := filepath.Base(url)
+		buildpack := freezer.NewLocalBuildpack(url, name)
+		key = buildpack.UncachedKey
+		if offline {
+			key = buildpack.CachedKey
+		}
+		dir = filepath.Join(g.cacheManager.Dir(), name)
+	}
+
+	if offline {
+		dir = filepath.Join(dir, "cached")
 	}
+
+	return key, dir, nil
+}
+
+func (g BuildpackStoreGet) target() (string, string) {
+	if g.platform == "" || g.arch == "" {
+		return "linux", "amd64"
+	}
+
+	return g.platform, g.arch
+}
+
+func parseGithubURL(url string) (string, string, error) {
+	request := strings.SplitN(url, "/", 3)
+	if len(request) < 3 {
+		return "", "", fmt.Errorf("error incomplete github.com url: %q", url)
+	}
+
+	return request[1], request[2], nil
 }
 
 func (g BuildpackStoreGet) WithOfflineDependencies() BuildpackStoreGet {
@@ -180,6 +723,15 @@ func (g BuildpackStoreGet) WithVersion(version string) BuildpackStoreGet {
 	return g
 }
 
+// pinVersion requests the given version like WithVersion, and also resolves
+// github.com references to the release of that version, rather than to the
+// latest release that the remote fetcher downloads.
+func (g BuildpackStoreGet) pinVersion(version string) BuildpackStoreGet {
+	g.version = version
+	g.pinned = version != ""
+	return g
+}
+
 type RegistryBuildpackImageExtractor struct {
 	docker Docker
 }
@@ -196,24 +748,56 @@ func (e RegistryBuildpackImageExtractor) Extract(ref string, destination string)
 		return "", "", fmt.Errorf("failed to pull buildpack image: %s", err)
 	}
 
-	img, err := e.docker.Image.ExportToOCI.Execute(ref)
+	// The layers of the image are only read from the daemon as they are
+	// decompressed, so the export is retried along with them, rather than on
+	// its own. Each retry starts from an empty destination, rather than from
+	// what the failed attempt decompressed.
+	export := e.docker.Image.ExportToOCI.WithRetryPolicy(RetryPolicy{})
+	var attempted bool
+	err = e.docker.retry.do(e.docker.ctx, func() error {
+		if attempted {
+			err := clearDir(destination)
+			if err != nil {
+				return fmt.Errorf("failed to clear %s: %w", destination, err)
+			}
+		}
+		attempted = true
+
+		return e.extractLayer(export, ref, destination)
+	})
 	if err != nil {
-		return "", "", fmt.Errorf("failed get oci image: %s", err)
+		return "", "", err
+	}
+
+	rootPath, version, err := e.GetRootPathAndVersionAndUpdateConfig(destination)
+	if err != nil {
+		return "", "", err
+	}
+
+	return rootPath, version, nil
+}
+
+// extractLayer decompresses the first layer of the image, which holds the
+// buildpack, into the destination.
+func (e RegistryBuildpackImageExtractor) extractLayer(export DockerImageOCI, ref, destination string) (err error) {
+	img, err := export.Execute(ref)
+	if err != nil {
+		return fmt.Errorf("failed get oci image: %w", err)
 	}
 
 	layers, err := img.Layers()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get image layers: %s", err)
+		return fmt.Errorf("failed to get image layers: %w", err)
 	}
 
 	if len(layers) == 0 {
-		return "", "", fmt.Errorf("no layers found in image")
+		return fmt.Errorf("no layers found in image")
 	}
 	layer0 := layers[0]
 
 	reader, err := layer0.Uncompressed()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get layer: %w", err)
+		return fmt.Errorf("failed to get layer: %w", err)
 	}
 	defer func() {
 		if err2 := reader.Close(); err2 != nil && err == nil {
@@ -223,15 +807,31 @@ func (e RegistryBuildpackImageExtractor) Extract(ref string, destination string)
 
 	err = vacation.NewArchive(reader).Decompress(destination)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decompress layer: %w", err)
+		return fmt.Errorf("failed to decompress layer: %w", err)
 	}
 
-	rootPath, version, err := e.GetRootPathAndVersionAndUpdateConfig(destination)
+	return nil
+}
+
+// clearDir removes the contents of the directory.
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return "", "", err
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
 	}
 
-	return rootPath, version, nil
+	for _, entry := range entries {
+		err = os.RemoveAll(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Get buildpack or extension root path and version, and update buildpack.toml or extension.toml so packager will work
@@ -0,0 +1,205 @@
+package occam
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/occam/internal/command"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// BuildpackStoreComposite assembles composite (meta) buildpacks. It reads the
+// [[order]] of the composite buildpack.toml, resolves every component
+// buildpack through the store, and packages them into a single .cnb using
+// `pack buildpack package`.
+//
+// Components are resolved from the reference registered with WithComponent,
+// or from github.com/<id> when no reference was registered. Each component is
+// requested at the version listed in the order, github.com components from
+// the release tagged with that version, and packaging fails when a different
+// version is resolved. Components without a version in the order are given
+// the version that was resolved, so that the package always matches its
+// dependencies. The composite is packaged for the target of the store.
+type BuildpackStoreComposite struct {
+	get        BuildpackStoreGet
+	pack       Executable
+	components map[string]string
+	tempOutput func(dir string, pattern string) (string, error)
+
+	offline bool
+	version string
+}
+
+func (bs BuildpackStore) Composite() BuildpackStoreComposite {
+	return BuildpackStoreComposite{
+		get:        bs.Get,
+		pack:       command.New("pack"),
+		components: map[string]string{},
+		tempOutput: os.MkdirTemp,
+	}
+}
+
+func (c BuildpackStoreComposite) WithPack(pack Executable) BuildpackStoreComposite {
+	c.pack = pack
+	return c
+}
+
+func (c BuildpackStoreComposite) WithTempOutput(tempOutput func(string, string) (string, error)) BuildpackStoreComposite {
+	c.tempOutput = tempOutput
+	return c
+}
+
+// WithComponent registers the reference (a local path, github.com URL or
+// registry image) used to resolve the component buildpack with the given ID.
+func (c BuildpackStoreComposite) WithComponent(id, ref string) BuildpackStoreComposite {
+	components := map[string]string{}
+	for key, value := range c.components {
+		components[key] = value
+	}
+	components[id] = ref
+
+	c.components = components
+	return c
+}
+
+func (c BuildpackStoreComposite) WithOfflineDependencies() BuildpackStoreComposite {
+	c.offline = true
+	return c
+}
+
+func (c BuildpackStoreComposite) WithVersion(version string) BuildpackStoreComposite {
+	c.version = version
+	return c
+}
+
+func (c BuildpackStoreComposite) Execute(buildpackDir string) (string, error) {
+	config, err := cargo.NewBuildpackParser().Parse(filepath.Join(buildpackDir, "buildpack.toml"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse composite buildpack.toml: %w", err)
+	}
+
+	if len(config.Order) == 0 {
+		return "", fmt.Errorf("failed to package composite buildpack: %s has no [[order]]", filepath.Join(buildpackDir, "buildpack.toml"))
+	}
+
+	// A component is requested at the first version listed for it in any
+	// group of the order.
+	pinned := map[string]string{}
+	for _, order := range config.Order {
+		for _, group := range order.Group {
+			if pinned[group.ID] == "" {
+				pinned[group.ID] = group.Version
+			}
+		}
+	}
+
+	var dependencies []string
+	versions := map[string]string{}
+	for _, order := range config.Order {
+		for _, group := range order.Group {
+			if _, ok := versions[group.ID]; ok {
+				continue
+			}
+
+			ref, ok := c.components[group.ID]
+			if !ok {
+				ref = fmt.Sprintf("github.com/%s", group.ID)
+			}
+
+			get := c.get.pinVersion(pinned[group.ID])
+			if c.offline {
+				get = get.WithOfflineDependencies()
+			}
+
+			path, version, err := get.execute(ref)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve component %s from %s: %w", group.ID, ref, err)
+			}
+
+			if version == "" {
+				version = pinned[group.ID]
+			}
+
+			versions[group.ID] = version
+			dependencies = append(dependencies, path)
+		}
+	}
+
+	for i, order := range config.Order {
+		for j, group := range order.Group {
+			if group.Version == "" {
+				config.Order[i].Group[j].Version = versions[group.ID]
+				continue
+			}
+
+			if group.Version != versions[group.ID] {
+				return "", fmt.Errorf("failed to resolve component %s: resolved version %s does not match version %s in the order", group.ID, versions[group.ID], group.Version)
+			}
+		}
+	}
+
+	if c.version != "" {
+		config.Buildpack.Version = c.version
+	}
+
+	output, err := c.tempOutput("", "composite")
+	if err != nil {
+		return "", err
+	}
+
+	compositeDir := filepath.Join(output, "buildpack")
+	err = fs.Copy(buildpackDir, compositeDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy composite buildpack: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(compositeDir, "buildpack.toml"), os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to open composite buildpack.toml: %w", err)
+	}
+
+	err = cargo.EncodeConfig(file, config)
+	if err != nil {
+		_ = file.Close()
+		return "", fmt.Errorf("failed to encode composite buildpack.toml: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to close composite buildpack.toml: %w", err)
+	}
+
+	packageToml := []string{"[buildpack]", fmt.Sprintf("uri = %q", compositeDir)}
+	for _, dependency := range dependencies {
+		packageToml = append(packageToml, "", "[[dependencies]]", fmt.Sprintf("uri = %q", dependency))
+	}
+
+	packageTomlPath := filepath.Join(output, "package.toml")
+	err = os.WriteFile(packageTomlPath, []byte(strings.Join(packageToml, "\n")+"\n"), 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to write package.toml: %w", err)
+	}
+
+	platform, arch := c.get.target()
+	cnbPath := filepath.Join(output, fmt.Sprintf("%s.cnb", filepath.Base(buildpackDir)))
+	err = command.WithContext(c.get.ctx, "pack", c.pack).Execute(pexec.Execution{
+		Args: []string{
+			"buildpack", "package",
+			cnbPath,
+			"--config", packageTomlPath,
+			"--format", "file",
+			"--target", fmt.Sprintf("%s/%s", platform, arch),
+		},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to package composite buildpack: %w", err)
+	}
+
+	return cnbPath, nil
+}
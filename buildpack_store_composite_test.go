@@ -0,0 +1,292 @@
+package occam_test
+
+import (
+	ctx "context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/freezer"
+	"github.com/paketo-buildpacks/freezer/github"
+	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuildpackStoreComposite(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		compositeDir      string
+		componentDir      string
+		outputDir         string
+		fakeLocalFetcher  *fakes.LocalFetcher
+		fakeRemoteFetcher *fakes.RemoteFetcher
+		fakeCacheManager  *fakes.CacheManager
+		fakeExtractor     *fakes.RegistryBuildpackToLocal
+		fakeReleases      *fakes.GitReleaseFetcher
+		pack              *fakes.Executable
+		store             occam.BuildpackStore
+		composite         occam.BuildpackStoreComposite
+	)
+
+	it.Before(func() {
+		compositeDir = filepath.Join(t.TempDir(), "some-composite")
+		Expect(os.MkdirAll(compositeDir, os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(compositeDir, "buildpack.toml"), []byte(`
+api = "0.8"
+
+[buildpack]
+  id = "some-org/some-composite"
+  name = "Some Composite"
+  version = "{{ .version }}"
+
+[[order]]
+  [[order.group]]
+    id = "some-org/local-component"
+    version = "1.0.0"
+
+  [[order.group]]
+    id = "some-org/github-component"
+
+[[order]]
+  [[order.group]]
+    id = "some-org/registry-component"
+    version = "3.0.0"
+    optional = true
+
+  [[order.group]]
+    id = "some-org/github-component"
+    version = "2.1.0"
+`), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(compositeDir, "README.md"), []byte("some-readme"), 0600)).To(Succeed())
+
+		componentDir = t.TempDir()
+		outputDir = t.TempDir()
+
+		fakeLocalFetcher = &fakes.LocalFetcher{}
+		fakeLocalFetcher.GetCall.Stub = func(buildpack freezer.LocalBuildpack) (string, error) {
+			return fmt.Sprintf("/cache/%s-%s.cnb", buildpack.Name, buildpack.Version), nil
+		}
+
+		fakeRemoteFetcher = &fakes.RemoteFetcher{}
+		fakeRemoteFetcher.GetCall.Returns.String = "/cache/github-component.cnb"
+
+		fakeCacheManager = &fakes.CacheManager{}
+		fakeCacheManager.GetCall.Returns.CacheEntry = freezer.CacheEntry{Version: "2.1.0"}
+		fakeCacheManager.GetCall.Returns.Bool = true
+
+		fakeExtractor = &fakes.RegistryBuildpackToLocal{}
+		fakeExtractor.ExtractCall.Returns.String_1 = "/some/extracted/path"
+		fakeExtractor.ExtractCall.Returns.String_2 = "3.0.0"
+
+		fakeReleases = &fakes.GitReleaseFetcher{}
+		fakeReleases.GetTagCall.Returns.Release = github.Release{TagName: "v2.1.0"}
+
+		pack = &fakes.Executable{}
+
+		store = occam.NewBuildpackStore().
+			WithLocalFetcher(fakeLocalFetcher).
+			WithRemoteFetcher(fakeRemoteFetcher).
+			WithCacheManager(fakeCacheManager).
+			WithRegistryBuildpackExtractor(fakeExtractor).
+			WithGitReleaseFetcher(fakeReleases)
+
+		composite = store.
+			Composite().
+			WithComponent("some-org/local-component", componentDir).
+			WithComponent("some-org/registry-component", "some-registry/registry-component").
+			WithPack(pack).
+			WithTempOutput(func(string, string) (string, error) { return outputDir, nil }).
+			WithVersion("9.9.9")
+	})
+
+	context("Execute", func() {
+		it("resolves every component and packages the composite", func() {
+			path, err := composite.Execute(compositeDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(outputDir, "some-composite.cnb")))
+
+			Expect(fakeLocalFetcher.GetCall.CallCount).To(Equal(2))
+			Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(1))
+			Expect(fakeRemoteFetcher.GetCall.Receives.RemoteBuildpack.Org).To(Equal("some-org"))
+			Expect(fakeRemoteFetcher.GetCall.Receives.RemoteBuildpack.Repo).To(Equal("github-component"))
+			Expect(fakeRemoteFetcher.GetCall.Receives.RemoteBuildpack.Version).To(Equal("2.1.0"))
+			Expect(fakeExtractor.ExtractCall.Receives.Ref).To(Equal("some-registry/registry-component"))
+
+			Expect(fakeReleases.GetCall.CallCount).To(Equal(0))
+			Expect(fakeReleases.GetTagCall.Receives.Org).To(Equal("some-org"))
+			Expect(fakeReleases.GetTagCall.Receives.Repo).To(Equal("github-component"))
+			Expect(fakeReleases.GetTagCall.Receives.Version).To(Equal("2.1.0"))
+
+			Expect(pack.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+				"buildpack", "package",
+				filepath.Join(outputDir, "some-composite.cnb"),
+				"--config", filepath.Join(outputDir, "package.toml"),
+				"--format", "file",
+				"--target", "linux/amd64",
+			}))
+
+			packageToml, err := os.ReadFile(filepath.Join(outputDir, "package.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(packageToml)).To(Equal(fmt.Sprintf(`[buildpack]
+uri = %q
+
+[[dependencies]]
+uri = %q
+
+[[dependencies]]
+uri = "/cache/github-component.cnb"
+
+[[dependencies]]
+uri = "/cache/registry-component-3.0.0.cnb"
+`, filepath.Join(outputDir, "buildpack"), fmt.Sprintf("/cache/%s-1.0.0.cnb", filepath.Base(componentDir)))))
+
+			Expect(filepath.Join(outputDir, "buildpack", "README.md")).To(BeARegularFile())
+
+			config, err := cargo.NewBuildpackParser().Parse(filepath.Join(outputDir, "buildpack", "buildpack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Buildpack.Version).To(Equal("9.9.9"))
+			Expect(config.Order).To(Equal([]cargo.ConfigOrder{
+				{
+					Group: []cargo.ConfigOrderGroup{
+						{ID: "some-org/local-component", Version: "1.0.0"},
+						{ID: "some-org/github-component", Version: "2.1.0"},
+					},
+				},
+				{
+					Group: []cargo.ConfigOrderGroup{
+						{ID: "some-org/registry-component", Version: "3.0.0", Optional: true},
+						{ID: "some-org/github-component", Version: "2.1.0"},
+					},
+				},
+			}))
+		})
+
+		context("when packaging with offline dependencies", func() {
+			it("resolves the components with offline dependencies", func() {
+				_, err := composite.WithOfflineDependencies().Execute(compositeDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeLocalFetcher.GetCall.Receives.LocalBuildpack.Offline).To(BeTrue())
+				Expect(fakeRemoteFetcher.GetCall.Receives.RemoteBuildpack.Offline).To(BeTrue())
+			})
+		})
+
+		context("when the store has a target", func() {
+			it("resolves the components and packages the composite for that target", func() {
+				_, err := store.WithTarget("linux/arm64").
+					Composite().
+					WithComponent("some-org/local-component", componentDir).
+					WithComponent("some-org/registry-component", "some-registry/registry-component").
+					WithPack(pack).
+					WithTempOutput(func(string, string) (string, error) { return outputDir, nil }).
+					Execute(compositeDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRemoteFetcher.GetCall.Receives.RemoteBuildpack.Arch).To(Equal("arm64"))
+				Expect(pack.ExecuteCall.Receives.Execution.Args).To(ContainElements("--target", "linux/arm64"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the buildpack.toml cannot be parsed", func() {
+				it("returns an error", func() {
+					_, err := composite.Execute(t.TempDir())
+					Expect(err).To(MatchError(ContainSubstring("failed to parse composite buildpack.toml")))
+				})
+			})
+
+			context("when the buildpack has no order", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(compositeDir, "buildpack.toml"), []byte(`
+api = "0.8"
+
+[buildpack]
+  id = "some-org/some-composite"
+`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := composite.Execute(compositeDir)
+					Expect(err).To(MatchError(fmt.Sprintf("failed to package composite buildpack: %s has no [[order]]", filepath.Join(compositeDir, "buildpack.toml"))))
+				})
+			})
+
+			context("when the context of the store is done", func() {
+				it("returns an error without packaging the composite", func() {
+					c, cancel := ctx.WithCancel(ctx.Background())
+					cancel()
+
+					_, err := occam.NewBuildpackStore().
+						WithContext(c).
+						WithLocalFetcher(fakeLocalFetcher).
+						WithRemoteFetcher(fakeRemoteFetcher).
+						WithCacheManager(fakeCacheManager).
+						WithRegistryBuildpackExtractor(fakeExtractor).
+						WithGitReleaseFetcher(fakeReleases).
+						Composite().
+						WithComponent("some-org/local-component", componentDir).
+						WithComponent("some-org/registry-component", "some-registry/registry-component").
+						WithPack(pack).
+						WithTempOutput(func(string, string) (string, error) { return outputDir, nil }).
+						Execute(compositeDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to package composite buildpack: command `pack buildpack package")))
+					Expect(errors.Is(err, ctx.Canceled)).To(BeTrue())
+					Expect(pack.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when a component cannot be resolved", func() {
+				it.Before(func() {
+					fakeRemoteFetcher.GetCall.Returns.Error = errors.New("some remote error")
+				})
+
+				it("returns an error", func() {
+					_, err := composite.Execute(compositeDir)
+					Expect(err).To(MatchError("failed to resolve component some-org/github-component from github.com/some-org/github-component: some remote error"))
+				})
+			})
+
+			context("when the release of a component cannot be found", func() {
+				it.Before(func() {
+					fakeReleases.GetTagCall.Returns.Error = errors.New("some release error")
+				})
+
+				it("returns an error", func() {
+					_, err := composite.Execute(compositeDir)
+					Expect(err).To(MatchError("failed to resolve component some-org/github-component from github.com/some-org/github-component: some release error"))
+					Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when a component resolves to a different version than the order", func() {
+				it.Before(func() {
+					fakeExtractor.ExtractCall.Returns.String_2 = "3.1.0"
+				})
+
+				it("returns an error", func() {
+					_, err := composite.Execute(compositeDir)
+					Expect(err).To(MatchError("failed to resolve component some-org/registry-component: resolved version 3.1.0 does not match version 3.0.0 in the order"))
+					Expect(pack.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when pack fails", func() {
+				it.Before(func() {
+					pack.ExecuteCall.Returns.Error = errors.New("some pack error")
+				})
+
+				it("returns an error", func() {
+					_, err := composite.Execute(compositeDir)
+					Expect(err).To(MatchError("failed to package composite buildpack: some pack error"))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,258 @@
+package occam
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BuildpackStoreLockfile records which release each github.com reference
+// resolved to, so that later runs resolve exactly the same buildpacks. The
+// digest is that of the release asset or source tarball, which is the same on
+// every machine. Local buildpack directories are packaged from source on
+// every run and are never locked.
+type BuildpackStoreLockfile struct {
+	Buildpacks []BuildpackStoreLock `json:"buildpacks"`
+}
+
+type BuildpackStoreLock struct {
+	URL     string `json:"url"`
+	Version string `json:"version"`
+	Digest  string `json:"digest"`
+	Target  string `json:"target,omitempty"`
+	Offline bool   `json:"offline,omitempty"`
+}
+
+// ParseBuildpackStoreLockfile reads the lockfile at the given path. A missing
+// file is treated as an empty lockfile.
+func ParseBuildpackStoreLockfile(path string) (BuildpackStoreLockfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return BuildpackStoreLockfile{}, nil
+		}
+
+		return BuildpackStoreLockfile{}, fmt.Errorf("failed to read buildpack store lockfile: %w", err)
+	}
+
+	var lockfile BuildpackStoreLockfile
+	err = json.Unmarshal(content, &lockfile)
+	if err != nil {
+		return BuildpackStoreLockfile{}, fmt.Errorf("failed to parse buildpack store lockfile: %w", err)
+	}
+
+	return lockfile, nil
+}
+
+// Write stores the lockfile at the given path with its entries in a stable
+// order so that it produces clean diffs when checked in.
+func (l BuildpackStoreLockfile) Write(path string) error {
+	sort.Slice(l.Buildpacks, func(i, j int) bool {
+		a, b := l.Buildpacks[i], l.Buildpacks[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+
+		return !a.Offline && b.Offline
+	})
+
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode buildpack store lockfile: %w", err)
+	}
+
+	// The lockfile is replaced rather than rewritten, so that it is never read
+	// while half written.
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack store lockfile: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(append(content, '\n'))
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write buildpack store lockfile: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack store lockfile: %w", err)
+	}
+
+	err = os.Chmod(file.Name(), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack store lockfile: %w", err)
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack store lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// Find returns the entry locking the given reference for a target and
+// offline setting.
+func (l BuildpackStoreLockfile) Find(url, target string, offline bool) (BuildpackStoreLock, bool) {
+	for _, lock := range l.Buildpacks {
+		if lock.URL == url && lock.Target == target && lock.Offline == offline {
+			return lock, true
+		}
+	}
+
+	return BuildpackStoreLock{}, false
+}
+
+// Upsert adds the entry to the lockfile, replacing any entry for the same
+// reference, target and offline setting.
+func (l BuildpackStoreLockfile) Upsert(lock BuildpackStoreLock) BuildpackStoreLockfile {
+	var buildpacks []BuildpackStoreLock
+	for _, existing := range l.Buildpacks {
+		if existing.URL == lock.URL && existing.Target == lock.Target && existing.Offline == lock.Offline {
+			continue
+		}
+
+		buildpacks = append(buildpacks, existing)
+	}
+
+	l.Buildpacks = append(buildpacks, lock)
+	return l
+}
+
+// lockfileMutex serializes the updates of lockfiles, so that buildpacks
+// resolved in parallel do not lose each other's entries.
+var lockfileMutex sync.Mutex
+
+// addToLockfile adds the entry to the lockfile at the given path. The lockfile
+// is read again while holding the mutex, which keeps the entries written
+// since it was first read.
+func addToLockfile(path string, lock BuildpackStoreLock) error {
+	lockfileMutex.Lock()
+	defer lockfileMutex.Unlock()
+
+	lockfile, err := ParseBuildpackStoreLockfile(path)
+	if err != nil {
+		return err
+	}
+
+	return lockfile.Upsert(lock).Write(path)
+}
+
+// sourceDigestSuffix names the file, next to a cached artifact, that records
+// the digest of the release asset or source tarball it came from.
+const sourceDigestSuffix = ".source-digest"
+
+// readSourceDigest returns the digest recorded for the cached artifact at the
+// given path, or an empty digest when none was recorded.
+func readSourceDigest(path string) (string, error) {
+	content, err := os.ReadFile(path + sourceDigestSuffix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to read source digest: %w", err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// writeSourceDigest records the digest next to the cached artifact at the
+// given path.
+func writeSourceDigest(path, digest string) error {
+	err := os.WriteFile(path+sourceDigestSuffix, []byte(digest+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write source digest: %w", err)
+	}
+
+	return nil
+}
+
+// fileDigest returns the digest of the file at the given path.
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// sourceDigest hashes the release asset or source tarball as the freezer
+// remote fetcher downloads it, so that the digest is that of the bytes that
+// were cached and the source is not downloaded a second time.
+type sourceDigest struct {
+	source io.ReadCloser
+	reader io.Reader
+	hash   hash.Hash
+}
+
+// open starts hashing a new download, dropping any earlier one, such as that
+// of a failed attempt.
+func (d *sourceDigest) open(source io.ReadCloser, err error) (io.ReadCloser, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	d.close()
+
+	d.source = source
+	d.hash = sha256.New()
+	d.reader = io.TeeReader(source, d.hash)
+
+	return io.NopCloser(d.reader), nil
+}
+
+// finish reads the rest of the download, which an archive reader may leave
+// unread, and returns its digest. The digest is empty when nothing was
+// downloaded.
+func (d *sourceDigest) finish() (string, error) {
+	if d.source == nil {
+		return "", nil
+	}
+	defer d.close()
+
+	_, err := io.Copy(io.Discard, d.reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read release source: %w", err)
+	}
+
+	return fmt.Sprintf("sha256:%x", d.hash.Sum(nil)), nil
+}
+
+func (d *sourceDigest) close() {
+	if d.source == nil {
+		return
+	}
+
+	if err := d.source.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to close release source: %s\n", err)
+	}
+
+	d.source = nil
+}
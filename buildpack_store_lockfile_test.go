@@ -0,0 +1,405 @@
+package occam_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/freezer"
+	freezerfakes "github.com/paketo-buildpacks/freezer/fakes"
+	"github.com/paketo-buildpacks/freezer/github"
+	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuildpackStoreLockfile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		lockfilePath string
+	)
+
+	it.Before(func() {
+		lockfilePath = filepath.Join(t.TempDir(), "buildpacks.lock.json")
+	})
+
+	context("BuildpackStoreLockfile", func() {
+		context("ParseBuildpackStoreLockfile", func() {
+			it("treats a missing file as an empty lockfile", func() {
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile).To(Equal(occam.BuildpackStoreLockfile{}))
+			})
+
+			context("when the file is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(lockfilePath, []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse buildpack store lockfile")))
+				})
+			})
+		})
+
+		it("upserts, finds and writes entries in a stable order", func() {
+			lockfile := occam.BuildpackStoreLockfile{}.
+				Upsert(occam.BuildpackStoreLock{URL: "some-registry/b", Version: "1.0.0"}).
+				Upsert(occam.BuildpackStoreLock{URL: "github.com/org/a", Version: "1.0.0", Target: "linux/arm64"}).
+				Upsert(occam.BuildpackStoreLock{URL: "github.com/org/a", Version: "1.0.0", Target: "linux/amd64"}).
+				Upsert(occam.BuildpackStoreLock{URL: "some-registry/b", Version: "2.0.0"})
+
+			lock, found := lockfile.Find("some-registry/b", "", false)
+			Expect(found).To(BeTrue())
+			Expect(lock.Version).To(Equal("2.0.0"))
+
+			_, found = lockfile.Find("some-registry/b", "", true)
+			Expect(found).To(BeFalse())
+
+			Expect(lockfile.Write(lockfilePath)).To(Succeed())
+
+			parsed, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Buildpacks).To(Equal([]occam.BuildpackStoreLock{
+				{URL: "github.com/org/a", Version: "1.0.0", Target: "linux/amd64"},
+				{URL: "github.com/org/a", Version: "1.0.0", Target: "linux/arm64"},
+				{URL: "some-registry/b", Version: "2.0.0"},
+			}))
+		})
+	})
+
+	context("when a store resolves through a lockfile", func() {
+		var (
+			buildpackStore        occam.BuildpackStore
+			cacheManager          freezer.CacheManager
+			fakeLocalFetcher      *fakes.LocalFetcher
+			fakeExtractor         *fakes.RegistryBuildpackToLocal
+			fakeGitReleaseFetcher *fakes.GitReleaseFetcher
+			fakePackager          *freezerfakes.Packager
+			sourceTarball         []byte
+		)
+
+		digest := func(content string) string {
+			return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+		}
+
+		it.Before(func() {
+			cacheManager = freezer.NewCacheManager(t.TempDir())
+
+			fakeLocalFetcher = &fakes.LocalFetcher{}
+
+			fakeExtractor = &fakes.RegistryBuildpackToLocal{}
+			fakeExtractor.ExtractCall.Returns.String_1 = "/some/local/path"
+			fakeExtractor.ExtractCall.Returns.String_2 = "3.0.0"
+
+			sourceTarball = tarFiles(map[string]string{"some-repo/buildpack.toml": "some-source"})
+
+			fakeGitReleaseFetcher = &fakes.GitReleaseFetcher{}
+			fakeGitReleaseFetcher.GetCall.Returns.Release = github.Release{
+				TagName:    "v2.0.0",
+				Assets:     []github.ReleaseAsset{{Name: "some-repo-2.0.0.cnb", URL: "latest-asset-url"}},
+				TarballURL: "latest-tarball-url",
+			}
+			fakeGitReleaseFetcher.GetTagCall.Returns.Release = github.Release{
+				TagName:    "v1.0.0",
+				Assets:     []github.ReleaseAsset{{Name: "some-repo-1.0.0.cnb", URL: "pinned-asset-url"}},
+				TarballURL: "pinned-tarball-url",
+			}
+			fakeGitReleaseFetcher.GetReleaseAssetCall.Stub = func(asset github.ReleaseAsset) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(strings.TrimSuffix(asset.URL, "-asset-url") + "-content")), nil
+			}
+			fakeGitReleaseFetcher.GetReleaseTarballCall.Stub = func(string) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(sourceTarball)), nil
+			}
+
+			// Packaging is not reproducible: every package is different.
+			fakePackager = &freezerfakes.Packager{}
+			fakePackager.ExecuteCall.Stub = func(_, output, _ string, _ bool) error {
+				return os.WriteFile(output, []byte(time.Now().String()), 0600)
+			}
+
+			buildpackStore = occam.NewBuildpackStore().
+				WithPackager(fakePackager).
+				WithCacheManager(&cacheManager).
+				WithLocalFetcher(fakeLocalFetcher).
+				WithRegistryBuildpackExtractor(fakeExtractor).
+				WithGitReleaseFetcher(fakeGitReleaseFetcher).
+				WithLockfile(lockfilePath)
+		})
+
+		context("when the references are not locked", func() {
+			it("resolves the latest release and records the digest of its asset", func() {
+				path, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.ReadFile(path)).To(Equal([]byte("latest-content")))
+
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile.Buildpacks).To(Equal([]occam.BuildpackStoreLock{
+					{
+						URL:     "github.com/some-org/some-repo",
+						Version: "2.0.0",
+						Digest:  digest("latest-content"),
+						Target:  "linux/amd64",
+					},
+				}))
+
+				Expect(fakeGitReleaseFetcher.GetCall.Receives.Org).To(Equal("some-org"))
+				Expect(fakeGitReleaseFetcher.GetCall.Receives.Repo).To(Equal("some-repo"))
+				Expect(fakeGitReleaseFetcher.GetTagCall.CallCount).To(Equal(0))
+				Expect(fakeGitReleaseFetcher.GetReleaseAssetCall.CallCount).To(Equal(1))
+			})
+
+			it("records the digest of the source of an offline buildpack", func() {
+				_, err := buildpackStore.Get.WithOfflineDependencies().Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile.Buildpacks).To(Equal([]occam.BuildpackStoreLock{
+					{
+						URL:     "github.com/some-org/some-repo",
+						Version: "2.0.0",
+						Digest:  digest(string(sourceTarball)),
+						Target:  "linux/amd64",
+						Offline: true,
+					},
+				}))
+			})
+
+			it("does not download a release again to find its digest", func() {
+				_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.Remove(lockfilePath)).To(Succeed())
+
+				_, err = buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile.Buildpacks[0].Digest).To(Equal(digest("latest-content")))
+				Expect(fakeGitReleaseFetcher.GetReleaseAssetCall.CallCount).To(Equal(1))
+			})
+
+			it("finds the digest of a release asset that was cached without one", func() {
+				_, err := buildpackStore.
+					WithRemoteFetcher(freezer.NewRemoteFetcher(&cacheManager, fakeGitReleaseFetcher, fakePackager)).
+					WithLockfile("").
+					Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile.Buildpacks[0].Digest).To(Equal(digest("latest-content")))
+				Expect(fakeGitReleaseFetcher.GetReleaseAssetCall.CallCount).To(Equal(1))
+			})
+
+			it("keeps every entry when references are resolved in parallel", func() {
+				// Every resolution has read the lockfile before any of them
+				// writes it.
+				releases := &waitingReleaseFetcher{GitReleaseFetcher: fakeGitReleaseFetcher}
+				releases.arrived.Add(20)
+
+				var wg sync.WaitGroup
+				for i := 0; i < 20; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						otherCache := freezer.NewCacheManager(t.TempDir())
+						_, err := buildpackStore.WithCacheManager(&otherCache).WithGitReleaseFetcher(releases).Get.
+							Execute(fmt.Sprintf("github.com/some-org/some-repo-%d", i))
+						Expect(err).NotTo(HaveOccurred())
+					}()
+				}
+				wg.Wait()
+
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile.Buildpacks).To(HaveLen(20))
+			})
+		})
+
+		context("when the references are locked", func() {
+			it.Before(func() {
+				Expect(occam.BuildpackStoreLockfile{
+					Buildpacks: []occam.BuildpackStoreLock{
+						{
+							URL:     "github.com/some-org/some-repo",
+							Version: "1.0.0",
+							Digest:  digest("pinned-content"),
+							Target:  "linux/amd64",
+						},
+						{
+							URL:     "github.com/some-org/some-repo",
+							Version: "1.0.0",
+							Digest:  digest(string(sourceTarball)),
+							Target:  "linux/amd64",
+							Offline: true,
+						},
+					},
+				}.Write(lockfilePath)).To(Succeed())
+			})
+
+			it("downloads the locked github release", func() {
+				path, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.ReadFile(path)).To(Equal([]byte("pinned-content")))
+
+				Expect(fakeGitReleaseFetcher.GetCall.CallCount).To(Equal(0))
+				Expect(fakeGitReleaseFetcher.GetTagCall.Receives.Org).To(Equal("some-org"))
+				Expect(fakeGitReleaseFetcher.GetTagCall.Receives.Repo).To(Equal("some-repo"))
+				Expect(fakeGitReleaseFetcher.GetTagCall.Receives.Version).To(Equal("1.0.0"))
+			})
+
+			it("packages a locked offline buildpack on a fresh cache", func() {
+				frozen := buildpackStore.WithFrozenLockfile(lockfilePath)
+
+				_, err := frozen.Get.WithOfflineDependencies().Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				otherCache := freezer.NewCacheManager(t.TempDir())
+				_, err = frozen.WithCacheManager(&otherCache).Get.WithOfflineDependencies().Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakePackager.ExecuteCall.CallCount).To(Equal(2))
+			})
+
+			context("when the downloaded release does not match the locked digest", func() {
+				it.Before(func() {
+					fakeGitReleaseFetcher.GetReleaseAssetCall.Stub = func(github.ReleaseAsset) (io.ReadCloser, error) {
+						return io.NopCloser(strings.NewReader("tampered-content")), nil
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+					Expect(err).To(MatchError(fmt.Sprintf(
+						"failed to resolve github.com/some-org/some-repo: digest %s does not match locked digest %s",
+						digest("tampered-content"), digest("pinned-content"),
+					)))
+				})
+			})
+
+			context("when the release has a different version", func() {
+				it.Before(func() {
+					fakeGitReleaseFetcher.GetTagCall.Returns.Release.TagName = "v1.0.1"
+				})
+
+				it("returns an error", func() {
+					_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+					Expect(err).To(MatchError("failed to resolve github.com/some-org/some-repo: resolved version 1.0.1 does not match locked version 1.0.0"))
+				})
+			})
+		})
+
+		context("when a remote fetcher is injected", func() {
+			var fakeRemoteFetcher *fakes.RemoteFetcher
+
+			it.Before(func() {
+				path := filepath.Join(t.TempDir(), "some-repo.cnb")
+				Expect(os.WriteFile(path, []byte("fetched-content"), 0600)).To(Succeed())
+
+				fakeRemoteFetcher = &fakes.RemoteFetcher{}
+				fakeRemoteFetcher.GetCall.Returns.String = path
+
+				buildpackStore = buildpackStore.WithRemoteFetcher(fakeRemoteFetcher)
+			})
+
+			it("fetches the pinned release through it", func() {
+				Expect(occam.BuildpackStoreLockfile{
+					Buildpacks: []occam.BuildpackStoreLock{
+						{
+							URL:     "github.com/some-org/some-repo",
+							Version: "1.0.0",
+							Target:  "linux/amd64",
+						},
+					},
+				}.Write(lockfilePath)).To(Succeed())
+
+				path, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path).To(Equal(fakeRemoteFetcher.GetCall.Returns.String))
+
+				Expect(fakeRemoteFetcher.GetCall.Receives.RemoteBuildpack.Version).To(Equal("1.0.0"))
+				Expect(fakeGitReleaseFetcher.GetReleaseAssetCall.CallCount).To(Equal(0))
+			})
+
+			it("locks the digest of the fetched asset", func() {
+				_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+
+				lockfile, err := occam.ParseBuildpackStoreLockfile(lockfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lockfile.Buildpacks).To(Equal([]occam.BuildpackStoreLock{
+					{
+						URL:     "github.com/some-org/some-repo",
+						Version: "2.0.0",
+						Digest:  digest("fetched-content"),
+						Target:  "linux/amd64",
+					},
+				}))
+			})
+		})
+
+		context("when resolving a registry reference", func() {
+			it("resolves a reference pinned by digest without locking it", func() {
+				fakeLocalFetcher.GetCall.Returns.String = "/path/to/registry.cnb"
+
+				path, err := buildpackStore.Get.Execute("some-registry/some-buildpack@sha256:some-digest")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path).To(Equal("/path/to/registry.cnb"))
+				Expect(lockfilePath).NotTo(BeAnExistingFile())
+			})
+
+			it("rejects a reference that is not pinned by digest", func() {
+				_, err := buildpackStore.Get.Execute("some-registry/some-buildpack")
+				Expect(err).To(MatchError("failed to resolve some-registry/some-buildpack: registry references cannot be locked, pin the image by digest (some-registry/some-buildpack@sha256:<digest>) instead"))
+				Expect(fakeExtractor.ExtractCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the lockfile is frozen", func() {
+			it.Before(func() {
+				buildpackStore = buildpackStore.WithFrozenLockfile(lockfilePath)
+			})
+
+			it("refuses to resolve references that are not locked", func() {
+				_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+				Expect(err).To(MatchError(fmt.Sprintf("failed to resolve github.com/some-org/some-repo: not found in frozen lockfile %s", lockfilePath)))
+
+				Expect(fakeGitReleaseFetcher.GetCall.CallCount).To(Equal(0))
+				Expect(lockfilePath).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+}
+
+// waitingReleaseFetcher holds every request for the latest release until the
+// expected number of requests has arrived.
+type waitingReleaseFetcher struct {
+	*fakes.GitReleaseFetcher
+
+	arrived sync.WaitGroup
+}
+
+func (f *waitingReleaseFetcher) Get(org, repo string) (github.Release, error) {
+	f.arrived.Done()
+	f.arrived.Wait()
+
+	return f.GitReleaseFetcher.Get(org, repo)
+}
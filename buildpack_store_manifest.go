@@ -0,0 +1,144 @@
+package occam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/freezer"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+)
+
+// BuildpackStoreManifest maps buildpack references to artifacts that already
+// exist on the local filesystem. It is read from a JSON file of the form:
+//
+//	{
+//	  "buildpacks": [
+//	    {
+//	      "url": "github.com/paketo-buildpacks/go-dist",
+//	      "version": "2.3.4",
+//	      "path": "/artifacts/go-dist.cnb",
+//	      "target": "linux/amd64",
+//	      "offline": false
+//	    }
+//	  ]
+//	}
+//
+// Relative paths are resolved against the directory containing the manifest.
+type BuildpackStoreManifest struct {
+	Buildpacks []BuildpackStoreManifestEntry `json:"buildpacks"`
+}
+
+type BuildpackStoreManifestEntry struct {
+	URL     string `json:"url"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Target  string `json:"target,omitempty"`
+	Offline bool   `json:"offline,omitempty"`
+}
+
+func ParseBuildpackStoreManifest(path string) (BuildpackStoreManifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return BuildpackStoreManifest{}, fmt.Errorf("failed to open buildpack store manifest: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close buildpack store manifest: %s\n", err)
+		}
+	}()
+
+	var manifest BuildpackStoreManifest
+	err = json.NewDecoder(file).Decode(&manifest)
+	if err != nil {
+		return BuildpackStoreManifest{}, fmt.Errorf("failed to parse buildpack store manifest: %w", err)
+	}
+
+	for i, entry := range manifest.Buildpacks {
+		if entry.URL == "" || entry.Path == "" {
+			return BuildpackStoreManifest{}, fmt.Errorf("failed to parse buildpack store manifest: entry %d must have a url and a path", i)
+		}
+
+		if entry.Target != "" && len(strings.Split(entry.Target, "/")) != 2 {
+			return BuildpackStoreManifest{}, fmt.Errorf("failed to parse buildpack store manifest: entry %d has malformed target %q", i, entry.Target)
+		}
+
+		if !filepath.IsAbs(entry.Path) {
+			manifest.Buildpacks[i].Path = filepath.Join(filepath.Dir(path), entry.Path)
+		}
+	}
+
+	return manifest, nil
+}
+
+// MissingBuildpacksError is returned by an air-gapped BuildpackStore when one
+// or more references cannot be served from the cache.
+type MissingBuildpacksError struct {
+	Missing []string
+}
+
+func (e MissingBuildpacksError) Error() string {
+	return fmt.Sprintf("air-gapped buildpack store is missing %d buildpack(s):\n  %s", len(e.Missing), strings.Join(e.Missing, "\n  "))
+}
+
+// seed copies every artifact listed in the manifest into the cache directory
+// and records it under the key the fetchers would have used. Entries that are
+// already cached at the same version are left untouched.
+func (g BuildpackStoreGet) seed(path string) error {
+	manifest, err := ParseBuildpackStoreManifest(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Buildpacks {
+		entryGet := g
+		if entry.Target != "" {
+			parts := strings.Split(entry.Target, "/")
+			entryGet.platform, entryGet.arch = parts[0], parts[1]
+		}
+
+		key, dir, err := entryGet.cacheLocation(entry.URL, entry.Offline)
+		if err != nil {
+			return err
+		}
+
+		cached, exists, err := g.cacheManager.Get(key)
+		if err != nil {
+			return fmt.Errorf("failed to read cache entry %q: %w", key, err)
+		}
+
+		if exists && cached.Version == entry.Version {
+			continue
+		}
+
+		err = os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("failed to seed cache for %q: %w", entry.URL, err)
+		}
+
+		destination := filepath.Join(dir, fmt.Sprintf("%s.cnb", entry.Version))
+		err = fs.Copy(entry.Path, destination)
+		if err != nil {
+			return fmt.Errorf("failed to seed cache for %q: %w", entry.URL, err)
+		}
+
+		// An entry whose artifact was deleted is reported as missing but still
+		// records the destination, and the cache manager removes the recorded
+		// artifact on Set, which would delete the copy that was just made.
+		if cached.URI == destination {
+			continue
+		}
+
+		err = g.cacheManager.Set(key, freezer.CacheEntry{
+			Version: entry.Version,
+			URI:     destination,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed cache for %q: %w", entry.URL, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package occam_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/freezer"
+	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuildpackStoreManifest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		manifestDir  string
+		cacheDir     string
+		cacheManager freezer.CacheManager
+	)
+
+	it.Before(func() {
+		manifestDir = t.TempDir()
+		cacheDir = t.TempDir()
+		cacheManager = freezer.NewCacheManager(cacheDir)
+
+		Expect(os.WriteFile(filepath.Join(manifestDir, "some-repo.cnb"), []byte("some-repo-content"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(manifestDir, "some-registry.cnb"), []byte("some-registry-content"), 0600)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(manifestDir, "manifest.json"), []byte(`{
+			"buildpacks": [
+				{
+					"url": "github.com/some-org/some-repo",
+					"version": "1.2.3",
+					"path": "some-repo.cnb",
+					"target": "linux/arm64"
+				},
+				{
+					"url": "some-registry/some-buildpack",
+					"version": "4.5.6",
+					"path": "some-registry.cnb",
+					"offline": true
+				}
+			]
+		}`), 0600)).To(Succeed())
+	})
+
+	context("ParseBuildpackStoreManifest", func() {
+		it("parses the manifest and resolves relative paths", func() {
+			manifest, err := occam.ParseBuildpackStoreManifest(filepath.Join(manifestDir, "manifest.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest).To(Equal(occam.BuildpackStoreManifest{
+				Buildpacks: []occam.BuildpackStoreManifestEntry{
+					{
+						URL:     "github.com/some-org/some-repo",
+						Version: "1.2.3",
+						Path:    filepath.Join(manifestDir, "some-repo.cnb"),
+						Target:  "linux/arm64",
+					},
+					{
+						URL:     "some-registry/some-buildpack",
+						Version: "4.5.6",
+						Path:    filepath.Join(manifestDir, "some-registry.cnb"),
+						Offline: true,
+					},
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when the file does not exist", func() {
+				it("returns an error", func() {
+					_, err := occam.ParseBuildpackStoreManifest(filepath.Join(manifestDir, "missing.json"))
+					Expect(err).To(MatchError(ContainSubstring("failed to open buildpack store manifest")))
+				})
+			})
+
+			context("when the file is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(manifestDir, "manifest.json"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := occam.ParseBuildpackStoreManifest(filepath.Join(manifestDir, "manifest.json"))
+					Expect(err).To(MatchError(ContainSubstring("failed to parse buildpack store manifest")))
+				})
+			})
+
+			context("when an entry has no path", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(manifestDir, "manifest.json"), []byte(`{"buildpacks": [{"url": "some-url"}]}`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := occam.ParseBuildpackStoreManifest(filepath.Join(manifestDir, "manifest.json"))
+					Expect(err).To(MatchError("failed to parse buildpack store manifest: entry 0 must have a url and a path"))
+				})
+			})
+		})
+	})
+
+	context("when a store is seeded from a manifest", func() {
+		var (
+			buildpackStore    occam.BuildpackStore
+			fakeRemoteFetcher *fakes.RemoteFetcher
+			fakeExtractor     *fakes.RegistryBuildpackToLocal
+		)
+
+		it.Before(func() {
+			fakeRemoteFetcher = &fakes.RemoteFetcher{}
+			fakeExtractor = &fakes.RegistryBuildpackToLocal{}
+
+			buildpackStore = occam.NewBuildpackStore().
+				WithRemoteFetcher(fakeRemoteFetcher).
+				WithRegistryBuildpackExtractor(fakeExtractor).
+				WithCacheManager(&cacheManager).
+				WithManifest(filepath.Join(manifestDir, "manifest.json")).
+				WithAirGapped()
+		})
+
+		it("serves the seeded artifacts from the cache", func() {
+			path, err := buildpackStore.WithTarget("linux/arm64").Get.
+				WithVersion("1.2.3").
+				Execute("github.com/some-org/some-repo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(cacheDir, "some-org", "some-repo", "linux", "arm64", "1.2.3.cnb")))
+			Expect(os.ReadFile(path)).To(Equal([]byte("some-repo-content")))
+
+			path, err = buildpackStore.Get.
+				WithOfflineDependencies().
+				Execute("some-registry/some-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(cacheDir, "some-buildpack", "cached", "4.5.6.cnb")))
+			Expect(os.ReadFile(path)).To(Equal([]byte("some-registry-content")))
+
+			Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(0))
+			Expect(fakeExtractor.ExtractCall.CallCount).To(Equal(0))
+		})
+
+		it("does not serve a seeded artifact for another target", func() {
+			err := buildpackStore.Get.Verify("github.com/some-org/some-repo")
+			Expect(err).To(MatchError(occam.MissingBuildpacksError{
+				Missing: []string{`github.com/some-org/some-repo (cache key "some-org:some-repo:linux:amd64")`},
+			}))
+		})
+
+		it("can be seeded repeatedly", func() {
+			Expect(buildpackStore.Get.Verify("some-registry/some-buildpack")).NotTo(Succeed())
+			Expect(buildpackStore.Get.WithOfflineDependencies().Verify("some-registry/some-buildpack")).To(Succeed())
+
+			path, err := buildpackStore.Get.
+				WithOfflineDependencies().
+				Execute("some-registry/some-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(path)).To(Equal([]byte("some-registry-content")))
+		})
+
+		it("seeds again when a cached artifact was deleted", func() {
+			path, err := buildpackStore.Get.
+				WithOfflineDependencies().
+				Execute("some-registry/some-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Remove(path)).To(Succeed())
+
+			path, err = buildpackStore.Get.
+				WithOfflineDependencies().
+				Execute("some-registry/some-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(path)).To(Equal([]byte("some-registry-content")))
+		})
+
+		context("failure cases", func() {
+			context("when an artifact in the manifest does not exist", func() {
+				it.Before(func() {
+					Expect(os.Remove(filepath.Join(manifestDir, "some-repo.cnb"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+					Expect(err).To(MatchError(ContainSubstring(`failed to seed cache for "github.com/some-org/some-repo"`)))
+				})
+			})
+		})
+	})
+}
@@ -1,17 +1,31 @@
 package occam_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
 	"github.com/sclevine/spec"
 
 	"github.com/paketo-buildpacks/freezer"
 
 	"github.com/paketo-buildpacks/occam"
 	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/occam/packagers"
 
 	. "github.com/onsi/gomega"
 )
@@ -35,6 +49,50 @@ func testBuildpackStore(t *testing.T, when spec.G, it spec.S) {
 		buildpackStore = occam.NewBuildpackStore()
 	})
 
+	when("describing the resolved buildpack", func() {
+		var cnbPath string
+
+		it.Before(func() {
+			cnbPath = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+			Expect(writeBuildpackPackageLayer(cnbPath, "some-org/some-buildpack", "1.2.3", map[string]string{
+				"buildpack.toml": "[[metadata.dependencies]]\nid = \"some-dependency\"\nuri = \"file:///dependencies/some-sha/some-dependency.tgz\"\n",
+			})).To(Succeed())
+
+			fakeRemoteFetcher.GetCall.Returns.String = cnbPath
+			buildpackStore = buildpackStore.WithLocalFetcher(fakeLocalFetcher).
+				WithRemoteFetcher(fakeRemoteFetcher).
+				WithCacheManager(fakeCacheManager)
+		})
+
+		it("returns the result of packaging it", func() {
+			content, err := os.ReadFile(cnbPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := buildpackStore.Get.
+				WithOfflineDependencies().
+				Result("github.com/some-org/some-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(packagers.Result{
+				Path:             cnbPath,
+				Digest:           fmt.Sprintf("sha256:%x", sha256.Sum256(content)),
+				BuildpackID:      "some-org/some-buildpack",
+				BuildpackVersion: "1.2.3",
+				Offline:          true,
+			}))
+		})
+
+		when("the buildpack cannot be resolved", func() {
+			it.Before(func() {
+				fakeRemoteFetcher.GetCall.Returns.Error = errors.New("some remote error")
+			})
+
+			it("returns an error", func() {
+				_, err := buildpackStore.Get.Result("github.com/some-org/some-buildpack")
+				Expect(err).To(MatchError("some remote error"))
+			})
+		})
+	})
+
 	when("getting an online buildpack", func() {
 		when("from a local uri", func() {
 			var localDir string
@@ -289,6 +347,270 @@ func testBuildpackStore(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("air-gapped", func() {
+		it.Before(func() {
+			fakeCacheManager.GetCall.Stub = func(key string) (freezer.CacheEntry, bool, error) {
+				switch key {
+				case "some-org:some-repo:linux:amd64":
+					return freezer.CacheEntry{Version: "1.2.3", URI: "/cache/some-repo.cnb"}, true, nil
+				case "some-registry-url:cached":
+					return freezer.CacheEntry{Version: "4.5.6", URI: "/cache/some-registry.cnb"}, true, nil
+				default:
+					return freezer.CacheEntry{}, false, nil
+				}
+			}
+			fakeLocalFetcher.GetCall.Returns.String = "/path/to/cool-buildpack/"
+
+			buildpackStore = buildpackStore.WithLocalFetcher(fakeLocalFetcher).
+				WithRemoteFetcher(fakeRemoteFetcher).
+				WithCacheManager(fakeCacheManager).
+				WithRegistryBuildpackExtractor(fakeExtractor).
+				WithAirGapped()
+		})
+
+		it("serves a github uri from the cache", func() {
+			path, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/cache/some-repo.cnb"))
+
+			Expect(fakeCacheManager.GetCall.Receives.Key).To(Equal("some-org:some-repo:linux:amd64"))
+			Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(0))
+		})
+
+		it("serves a registry uri from the cache", func() {
+			path, err := buildpackStore.Get.
+				WithOfflineDependencies().
+				Execute("some-registry-url")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/cache/some-registry.cnb"))
+
+			Expect(fakeExtractor.ExtractCall.CallCount).To(Equal(0))
+			Expect(fakeLocalFetcher.GetCall.CallCount).To(Equal(0))
+		})
+
+		it("still packages local buildpacks", func() {
+			path, err := buildpackStore.Get.Execute(t.TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/path/to/cool-buildpack/"))
+			Expect(fakeLocalFetcher.GetCall.CallCount).To(Equal(1))
+		})
+
+		when("the reference is not cached", func() {
+			it("fails without fetching it", func() {
+				_, err := buildpackStore.Get.Execute("github.com/some-org/other-repo")
+				Expect(err).To(MatchError(occam.MissingBuildpacksError{
+					Missing: []string{`github.com/some-org/other-repo (cache key "some-org:other-repo:linux:amd64")`},
+				}))
+
+				Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(0))
+			})
+		})
+
+		when("the cached version does not match the requested version", func() {
+			it("fails without fetching it", func() {
+				_, err := buildpackStore.Get.
+					WithVersion("2.0.0").
+					Execute("github.com/some-org/some-repo")
+				Expect(err).To(MatchError(occam.MissingBuildpacksError{
+					Missing: []string{`github.com/some-org/some-repo@2.0.0 (cache key "some-org:some-repo:linux:amd64")`},
+				}))
+
+				Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(0))
+			})
+		})
+
+		when("verifying a set of references", func() {
+			it("reports every missing reference", func() {
+				err := buildpackStore.Get.Verify(
+					t.TempDir(),
+					"github.com/some-org/some-repo",
+					"github.com/some-org/other-repo",
+					"some-registry-url",
+				)
+				Expect(err).To(MatchError(ContainSubstring("missing 2 buildpack(s)")))
+
+				var missingErr occam.MissingBuildpacksError
+				Expect(errors.As(err, &missingErr)).To(BeTrue())
+				Expect(missingErr.Missing).To(Equal([]string{
+					`github.com/some-org/other-repo (cache key "some-org:other-repo:linux:amd64")`,
+					`some-registry-url (cache key "some-registry-url")`,
+				}))
+
+				Expect(fakeCacheManager.OpenCall.CallCount).To(Equal(1))
+				Expect(fakeCacheManager.CloseCall.CallCount).To(Equal(1))
+			})
+
+			it("succeeds when everything is cached", func() {
+				err := buildpackStore.Get.Verify("github.com/some-org/some-repo")
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	when("WithRetryPolicy", func() {
+		it.Before(func() {
+			buildpackStore = buildpackStore.WithLocalFetcher(fakeLocalFetcher).
+				WithRemoteFetcher(fakeRemoteFetcher).
+				WithCacheManager(fakeCacheManager).
+				WithRegistryBuildpackExtractor(fakeExtractor).
+				WithRetryPolicy(occam.RetryPolicy{Attempts: 3})
+		})
+
+		it("retries a github uri that fails with a transient error", func() {
+			fakeRemoteFetcher.GetCall.Stub = func(freezer.RemoteBuildpack) (string, error) {
+				if fakeRemoteFetcher.GetCall.CallCount < 3 {
+					return "", errors.New("unexpected response status: 502 Bad Gateway")
+				}
+
+				return "/path/to/remote-buildpack", nil
+			}
+
+			path, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/path/to/remote-buildpack"))
+			Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(3))
+		})
+
+		it("leaves retrying a registry uri to the extractor", func() {
+			fakeExtractor.ExtractCall.Returns.Error = errors.New("net/http: TLS handshake timeout")
+
+			_, err := buildpackStore.Get.Execute("some-registry-url")
+			Expect(err).To(MatchError("failed to create local buildpack from registry image: net/http: TLS handshake timeout"))
+			Expect(fakeExtractor.ExtractCall.CallCount).To(Equal(1))
+		})
+
+		when("the registry buildpack image extractor is used", func() {
+			var (
+				docker      occam.Docker
+				destination string
+				saves       int
+			)
+
+			it.Before(func() {
+				destination = ""
+				saves = 0
+
+				layer := tarFiles(map[string]string{
+					"cnb/buildpacks/some-buildpack/1.2.3/buildpack.toml": "api = \"0.8\"\n\n[buildpack]\n  id = \"some-buildpack\"\n  version = \"1.2.3\"\n",
+				})
+				img, err := mutate.AppendLayers(empty.Image, static.NewLayer(layer, types.DockerLayer))
+				Expect(err).NotTo(HaveOccurred())
+
+				digest, err := img.Digest()
+				Expect(err).NotTo(HaveOccurred())
+
+				daemonClient := &fakes.DockerDaemonClient{}
+				daemonClient.ImageInspectCall.Stub = func(context.Context, string, ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+					return client.ImageInspectResult{InspectResponse: image.InspectResponse{ID: digest.String()}}, nil
+				}
+				daemonClient.ImageSaveCall.Stub = func(context.Context, []string, ...client.ImageSaveOption) (client.ImageSaveResult, error) {
+					saves++
+					if saves == 1 {
+						if destination != "" {
+							Expect(os.WriteFile(filepath.Join(destination, "partial-file"), nil, 0600)).To(Succeed())
+						}
+
+						return nil, errors.New("read: connection reset by peer")
+					}
+
+					buffer := bytes.NewBuffer(nil)
+					ref, err := name.ParseReference("some-registry/some-buildpack")
+					if err != nil {
+						return nil, err
+					}
+
+					err = tarball.Write(ref, img, buffer)
+					if err != nil {
+						return nil, err
+					}
+
+					return io.NopCloser(buffer), nil
+				}
+
+				docker = occam.NewDocker().WithExecutable(&fakes.Executable{})
+				docker.Image.ExportToOCI = docker.Image.ExportToOCI.WithClient(daemonClient)
+
+				buildpackStore = buildpackStore.WithRegistryBuildpackExtractor(occam.NewRegistryBuildpackImageExtractor(docker))
+			})
+
+			it("gives it the policy", func() {
+				fakeLocalFetcher.GetCall.Returns.String = "/path/to/registry-buildpack"
+
+				path, err := buildpackStore.Get.Execute("some-registry/some-buildpack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path).To(Equal("/path/to/registry-buildpack"))
+				Expect(saves).To(Equal(2))
+				Expect(fakeLocalFetcher.GetCall.Receives.LocalBuildpack.Path).To(HaveSuffix(filepath.Join("cnb", "buildpacks", "some-buildpack", "1.2.3")))
+			})
+
+			it("retries extracting from an empty destination", func() {
+				destination = t.TempDir()
+
+				root, version, err := occam.NewRegistryBuildpackImageExtractor(docker.WithRetryPolicy(occam.RetryPolicy{Attempts: 2})).
+					Extract("some-registry/some-buildpack", destination)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(root).To(Equal(filepath.Join(destination, "cnb", "buildpacks", "some-buildpack", "1.2.3")))
+				Expect(version).To(Equal("1.2.3"))
+				Expect(saves).To(Equal(2))
+				Expect(filepath.Join(destination, "partial-file")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		it("reports every attempt when they all fail", func() {
+			fakeRemoteFetcher.GetCall.Returns.Error = errors.New("read: connection reset by peer")
+
+			_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+			Expect(err).To(MatchError("failed after 3 attempts:\n" +
+				"  attempt 1: read: connection reset by peer\n" +
+				"  attempt 2: read: connection reset by peer\n" +
+				"  attempt 3: read: connection reset by peer"))
+
+			var retryErr occam.RetryError
+			Expect(errors.As(err, &retryErr)).To(BeTrue())
+			Expect(retryErr.Attempts).To(HaveLen(3))
+		})
+
+		it("does not retry an error that is not transient", func() {
+			fakeRemoteFetcher.GetCall.Returns.Error = errors.New("unexpected response status: 404 Not Found")
+
+			_, err := buildpackStore.Get.Execute("github.com/some-org/some-repo")
+			Expect(err).To(MatchError("unexpected response status: 404 Not Found"))
+			Expect(fakeRemoteFetcher.GetCall.CallCount).To(Equal(1))
+		})
+	})
+
+	when("WithContext", func() {
+		it("stops the packager when the context is done", func() {
+			t.Setenv("HOME", t.TempDir())
+
+			c, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			jam := &fakes.Executable{}
+			_, err := occam.NewBuildpackStore().
+				WithContext(c).
+				WithPackager(packagers.NewJam().WithExecutable(jam)).
+				Get.Execute(t.TempDir())
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(jam.ExecuteCall.CallCount).To(Equal(0))
+		})
+
+		it("stops the packager set before the context", func() {
+			t.Setenv("HOME", t.TempDir())
+
+			c, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			jam := &fakes.Executable{}
+			_, err := occam.NewBuildpackStore().
+				WithPackager(packagers.NewJam().WithExecutable(jam)).
+				WithContext(c).
+				Get.Execute(t.TempDir())
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(jam.ExecuteCall.CallCount).To(Equal(0))
+		})
+	})
+
 	when("failure cases", func() {
 		when("unable to open cacheManager", func() {
 			it.Before(func() {
@@ -0,0 +1,232 @@
+package occam
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// cacheMetadataName is the name of the file, or image label, holding the
+// metadata of a cache.
+const cacheMetadataName = "io.buildpacks.lifecycle.cache.metadata"
+
+type CacheType string
+
+const (
+	CacheTypeBuild  CacheType = "build"
+	CacheTypeLaunch CacheType = "launch"
+)
+
+type CacheFormat string
+
+const (
+	CacheFormatVolume CacheFormat = "volume"
+	CacheFormatImage  CacheFormat = "image"
+	CacheFormatBind   CacheFormat = "bind"
+)
+
+// Cache is a build or launch cache of `pack build`, as given to the `--cache`
+// flag.
+type Cache struct {
+	Type   CacheType
+	Format CacheFormat
+
+	// Name is the name of a volume cache, or the reference of an image cache.
+	Name string
+
+	// Source is the host directory of a bind cache.
+	Source string
+}
+
+func NewVolumeCache(cacheType CacheType, name string) Cache {
+	return Cache{Type: cacheType, Format: CacheFormatVolume, Name: name}
+}
+
+// NewImageCache returns a build cache stored in the image with the given
+// reference. Pack only supports image caches for the build cache, and only
+// when publishing.
+func NewImageCache(ref string) Cache {
+	return Cache{Type: CacheTypeBuild, Format: CacheFormatImage, Name: ref}
+}
+
+func NewBindCache(cacheType CacheType, source string) Cache {
+	return Cache{Type: cacheType, Format: CacheFormatBind, Source: source}
+}
+
+// ParseCache parses the value of a `--cache` flag, such as
+// "type=build;format=volume;name=some-volume". The format defaults to volume,
+// as it does in pack.
+func ParseCache(value string) (Cache, error) {
+	cache := Cache{Format: CacheFormatVolume}
+	for _, field := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return Cache{}, fmt.Errorf("failed to parse cache %q: invalid field %q", value, field)
+		}
+
+		switch key {
+		case "type":
+			cache.Type = CacheType(val)
+		case "format":
+			cache.Format = CacheFormat(val)
+		case "name":
+			cache.Name = val
+		case "source":
+			cache.Source = val
+		default:
+			return Cache{}, fmt.Errorf("failed to parse cache %q: unknown key %q", value, key)
+		}
+	}
+
+	switch cache.Type {
+	case CacheTypeBuild, CacheTypeLaunch:
+	default:
+		return Cache{}, fmt.Errorf("failed to parse cache %q: unknown type %q", value, cache.Type)
+	}
+
+	switch cache.Format {
+	case CacheFormatVolume, CacheFormatImage:
+		if cache.Name == "" {
+			return Cache{}, fmt.Errorf("failed to parse cache %q: missing name", value)
+		}
+	case CacheFormatBind:
+		if cache.Source == "" {
+			return Cache{}, fmt.Errorf("failed to parse cache %q: missing source", value)
+		}
+	default:
+		return Cache{}, fmt.Errorf("failed to parse cache %q: unknown format %q", value, cache.Format)
+	}
+
+	return cache, nil
+}
+
+// String returns the cache as the value of a `--cache` flag.
+func (c Cache) String() string {
+	if c.Format == CacheFormatBind {
+		return fmt.Sprintf("type=%s;format=%s;source=%s", c.Type, c.Format, c.Source)
+	}
+
+	return fmt.Sprintf("type=%s;format=%s;name=%s", c.Type, c.Format, c.Name)
+}
+
+// DefaultCaches returns the volume caches that PackBuild gives to the build of
+// the image with the given name when it is not given any. They have the names
+// pack itself gives to cache volumes.
+func DefaultCaches(imageName string) []Cache {
+	return []Cache{
+		NewVolumeCache(CacheTypeBuild, cacheVolumeName(imageName, string(CacheTypeBuild))),
+		NewVolumeCache(CacheTypeLaunch, cacheVolumeName(imageName, string(CacheTypeLaunch))),
+	}
+}
+
+// cacheVolumeName returns the name pack gives to the cache volume with the
+// given suffix for the image with the given name.
+func cacheVolumeName(imageName, suffix string) string {
+	name, sum := cacheVolumeKey(imageName)
+	return fmt.Sprintf("pack-cache-%s_latest-%x.%s", name, sum, suffix)
+}
+
+// cacheVolumeKey returns the repository name, without its registry, and the
+// truncated hash that pack uses to name the cache volumes of an image.
+func cacheVolumeKey(imageName string) (string, []byte) {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:latest", imageName)))
+
+	name := imageName
+	parts := strings.SplitN(imageName, "/", 2)
+	if len(parts) == 2 {
+		name = parts[1]
+	}
+
+	return name, sum[:6]
+}
+
+// CacheContents is what the lifecycle stored in a cache, as returned by
+// Docker.Cache.Inspect.
+type CacheContents struct {
+	Cache Cache
+
+	// Image holds the cached layers, so that the file matchers can be run
+	// against the files buildpacks cached, at their paths under /layers.
+	Image v1.Image
+
+	// Buildpacks describes the layers each buildpack cached. It is empty for
+	// a launch cache, which holds no metadata.
+	Buildpacks []ImageBuildpackMetadata
+}
+
+func (c CacheContents) BuildpackForKey(key string) (ImageBuildpackMetadata, error) {
+	return Image{Buildpacks: c.Buildpacks}.BuildpackForKey(key)
+}
+
+// readCommittedCache reads the contents of a volume or bind cache from its
+// committed directory, which holds a tarball per cached layer alongside the
+// cache metadata. The layers are read lazily from the directory, unless
+// inMemory is set for a directory that is about to be removed.
+func readCommittedCache(cache Cache, dir string, inMemory bool) (CacheContents, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return CacheContents{}, err
+	}
+
+	var layers []v1.Layer
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tar" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		var layer v1.Layer
+		if inMemory {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return CacheContents{}, err
+			}
+
+			layer, err = tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(content)), nil
+			})
+		} else {
+			layer, err = tarball.LayerFromFile(path)
+		}
+		if err != nil {
+			return CacheContents{}, err
+		}
+
+		layers = append(layers, layer)
+	}
+
+	image, err := mutate.AppendLayers(empty.Image, layers...)
+	if err != nil {
+		return CacheContents{}, err
+	}
+
+	var buildpacks []ImageBuildpackMetadata
+	content, err := os.ReadFile(filepath.Join(dir, cacheMetadataName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return CacheContents{}, err
+	}
+
+	if err == nil {
+		buildpacks, err = parseBuildpackMetadata(content)
+		if err != nil {
+			return CacheContents{}, fmt.Errorf("failed to parse cache metadata: %w", err)
+		}
+	}
+
+	return CacheContents{
+		Cache:      cache,
+		Image:      image,
+		Buildpacks: buildpacks,
+	}, nil
+}
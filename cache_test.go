@@ -0,0 +1,89 @@
+package occam_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/occam"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCache(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("DefaultCaches", func() {
+		it("returns the build and launch volume caches pack names after the image", func() {
+			Expect(occam.DefaultCaches("occam.example.com/some-app")).To(Equal([]occam.Cache{
+				{Type: occam.CacheTypeBuild, Format: occam.CacheFormatVolume, Name: "pack-cache-some-app_latest-e69d3a4f1e12.build"},
+				{Type: occam.CacheTypeLaunch, Format: occam.CacheFormatVolume, Name: "pack-cache-some-app_latest-e69d3a4f1e12.launch"},
+			}))
+		})
+
+		it("returns caches that are among the CacheVolumeNames", func() {
+			for _, cache := range occam.DefaultCaches("some-app") {
+				Expect(occam.CacheVolumeNames("some-app")).To(ContainElement(cache.Name))
+			}
+		})
+	})
+
+	context("String", func() {
+		it("returns the value of the --cache flag", func() {
+			Expect(occam.NewVolumeCache(occam.CacheTypeLaunch, "some-volume").String()).To(Equal("type=launch;format=volume;name=some-volume"))
+			Expect(occam.NewImageCache("some-registry/some-cache").String()).To(Equal("type=build;format=image;name=some-registry/some-cache"))
+			Expect(occam.NewBindCache(occam.CacheTypeBuild, "/some/dir").String()).To(Equal("type=build;format=bind;source=/some/dir"))
+		})
+	})
+
+	context("ParseCache", func() {
+		it("parses the value of the --cache flag", func() {
+			for _, cache := range []occam.Cache{
+				occam.NewVolumeCache(occam.CacheTypeLaunch, "some-volume"),
+				occam.NewImageCache("some-registry/some-cache"),
+				occam.NewBindCache(occam.CacheTypeBuild, "/some/dir"),
+			} {
+				parsed, err := occam.ParseCache(cache.String())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(parsed).To(Equal(cache))
+			}
+		})
+
+		it("defaults to a volume cache", func() {
+			cache, err := occam.ParseCache("type=build;name=some-volume")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cache).To(Equal(occam.NewVolumeCache(occam.CacheTypeBuild, "some-volume")))
+		})
+
+		context("failure cases", func() {
+			it("returns an error for an invalid field", func() {
+				_, err := occam.ParseCache("type=build;volume")
+				Expect(err).To(MatchError(`failed to parse cache "type=build;volume": invalid field "volume"`))
+			})
+
+			it("returns an error for an unknown key", func() {
+				_, err := occam.ParseCache("type=build;size=1G")
+				Expect(err).To(MatchError(`failed to parse cache "type=build;size=1G": unknown key "size"`))
+			})
+
+			it("returns an error for an unknown type", func() {
+				_, err := occam.ParseCache("type=run;name=some-volume")
+				Expect(err).To(MatchError(`failed to parse cache "type=run;name=some-volume": unknown type "run"`))
+			})
+
+			it("returns an error for an unknown format", func() {
+				_, err := occam.ParseCache("type=build;format=tmpfs;name=some-volume")
+				Expect(err).To(MatchError(`failed to parse cache "type=build;format=tmpfs;name=some-volume": unknown format "tmpfs"`))
+			})
+
+			it("returns an error for a volume cache without a name", func() {
+				_, err := occam.ParseCache("type=build;format=volume")
+				Expect(err).To(MatchError(`failed to parse cache "type=build;format=volume": missing name`))
+			})
+
+			it("returns an error for a bind cache without a source", func() {
+				_, err := occam.ParseCache("type=build;format=bind")
+				Expect(err).To(MatchError(`failed to parse cache "type=build;format=bind": missing source`))
+			})
+		})
+	})
+}
@@ -3,26 +3,21 @@ package occam
 import (
 	"crypto/sha256"
 	"fmt"
-	"strings"
 )
 
+// CacheVolumeNames returns the names of every cache volume pack may have
+// created for the image with the given name, in current and legacy formats.
+// The caches of a single build are given by PackBuild.Caches.
 func CacheVolumeNames(volumeName string) []string {
-	name := volumeName
-	refName := []byte(fmt.Sprintf("%s:latest", name))
-	sum := sha256.Sum256(refName)
-
-	parts := strings.SplitN(volumeName, "/", 2)
-	if len(parts) == 2 {
-		name = parts[1]
-	}
+	name, sum := cacheVolumeKey(volumeName)
 
 	var volumes []string
 	for _, t := range []string{"build", "launch", "cache"} {
-		volumes = append(volumes, fmt.Sprintf("pack-cache-%x.%s", sum[:6], t))
-		volumes = append(volumes, fmt.Sprintf("pack-cache-%s_latest-%x.%s", name, sum[:6], t))
+		volumes = append(volumes, fmt.Sprintf("pack-cache-%x.%s", sum, t))
+		volumes = append(volumes, cacheVolumeName(volumeName, t))
 	}
 
-	kanikoRefName := []byte(fmt.Sprintf("%s%s-volume", refName, volumeName))
+	kanikoRefName := []byte(fmt.Sprintf("%s:latest%s-volume", volumeName, volumeName))
 	kanikoSum := sha256.Sum256(kanikoRefName)
 	volumes = append(volumes, fmt.Sprintf("pack-cache-%s_latest-%x.kaniko", name, kanikoSum[:6]))
 
@@ -2,8 +2,10 @@ package occam
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 )
 
@@ -12,11 +14,12 @@ type ContainerStructureTest struct {
 	verbose    bool
 	noColor    bool
 	pull       bool
+	ctx        context.Context
 }
 
 func NewContainerStructureTest() ContainerStructureTest {
 	return ContainerStructureTest{
-		executable: pexec.NewExecutable("container-structure-test"),
+		executable: command.New("container-structure-test"),
 	}
 }
 
@@ -25,6 +28,14 @@ func (c ContainerStructureTest) WithExecutable(executable Executable) ContainerS
 	return c
 }
 
+// WithContext stops container-structure-test when the context is done. It is
+// killed, and Execute fails with an error saying it timed out, followed by the
+// output it wrote until then.
+func (c ContainerStructureTest) WithContext(ctx context.Context) ContainerStructureTest {
+	c.ctx = ctx
+	return c
+}
+
 func (c ContainerStructureTest) WithVerbose() ContainerStructureTest {
 	c.verbose = true
 	return c
@@ -58,7 +69,7 @@ func (r ContainerStructureTest) Execute(imageID string, config string) (string,
 	args = append(args, "--config", config, "--image", imageID)
 
 	log := bytes.NewBuffer(nil)
-	err := r.executable.Execute(pexec.Execution{
+	err := command.WithContext(r.ctx, "container-structure-test", r.executable).Execute(pexec.Execution{
 		Args:   args,
 		Stdout: log,
 		Stderr: log,
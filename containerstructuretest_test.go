@@ -1,6 +1,8 @@
 package occam_test
 
 import (
+	ctx "context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -99,5 +101,23 @@ func testContainerStructureTest(t *testing.T, context spec.G, it spec.S) {
 				}))
 			})
 		})
+
+		context("WithContext", func() {
+			it("returns a cancellation error with the output so far", func() {
+				c, cancel := ctx.WithCancel(ctx.Background())
+				defer cancel()
+
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stdout, "some partial output")
+					cancel()
+					return errors.New("signal: killed")
+				}
+
+				logs, err := cst.WithContext(c).Execute("test/my-image", "tests.yaml")
+				Expect(err).To(MatchError("failed to run container-structure-test: command `container-structure-test test --config tests.yaml --image test/my-image` was cancelled: context canceled\n\nOutput:\nsome partial output\n"))
+				Expect(errors.Is(err, ctx.Canceled)).To(BeTrue())
+				Expect(logs).To(Equal("some partial output\n"))
+			})
+		})
 	})
 }
@@ -2,16 +2,26 @@ package occam
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/moby/moby/client"
+	"github.com/google/go-containerregistry/pkg/authn"
 	name "github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	daemon "github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
 )
 
 //go:generate faux --interface DockerDaemonClient --output fakes/daemon_client.go
@@ -36,18 +46,29 @@ type Docker struct {
 		Restart DockerContainerRestart
 		Run     DockerContainerRun
 		Stop    DockerContainerStop
+		Wait    DockerContainerWait
 	}
 
 	Volume struct {
 		Remove DockerVolumeRemove
 	}
 
+	Cache struct {
+		Inspect DockerCacheInspect
+		Remove  DockerCacheRemove
+	}
+
 	Pull DockerPull
+
+	executable Executable
+	ctx        context.Context
+	retry      RetryPolicy
 }
 
 func NewDocker() Docker {
 	var docker Docker
-	executable := pexec.NewExecutable("docker")
+	executable := command.New("docker")
+	docker.executable = executable
 
 	docker.Image.Inspect = DockerImageInspect{executable: executable}
 	docker.Image.Remove = DockerImageRemove{executable: executable}
@@ -66,15 +87,49 @@ func NewDocker() Docker {
 	docker.Container.Remove = DockerContainerRemove{executable: executable}
 	docker.Container.Restart = DockerContainerRestart{executable: executable}
 	docker.Container.Stop = DockerContainerStop{executable: executable}
+	docker.Container.Wait = DockerContainerWait{executable: executable}
 
 	docker.Volume.Remove = DockerVolumeRemove{executable: executable}
 
+	docker.Cache.Inspect = DockerCacheInspect{
+		executable: executable,
+		image:      "busybox:latest",
+	}
+	docker.Cache.Remove = DockerCacheRemove{
+		volumeRemove: docker.Volume.Remove,
+		imageRemove:  docker.Image.Remove.WithForce(),
+	}
+
 	docker.Pull = DockerPull{executable: executable}
 
 	return docker
 }
 
+// WithContext stops the docker commands when the context is done. Docker is
+// killed, and the command fails with an error saying it timed out, followed
+// by the error output docker wrote until then.
+func (d Docker) WithContext(ctx context.Context) Docker {
+	d.ctx = ctx
+	d.Pull.ctx = ctx
+	d.Image.ExportToOCI.ctx = ctx
+	return d.WithExecutable(d.executable)
+}
+
+// WithRetryPolicy retries the operations that reach a registry, or read a
+// whole image from the daemon, when they fail with a transient error: pulls,
+// exports to OCI, and the layers read by RegistryBuildpackImageExtractor.
+// Every attempt is reported in the final error, a RetryError.
+func (d Docker) WithRetryPolicy(policy RetryPolicy) Docker {
+	d.retry = policy
+	d.Pull = d.Pull.WithRetryPolicy(policy)
+	d.Image.ExportToOCI = d.Image.ExportToOCI.WithRetryPolicy(policy)
+	return d
+}
+
 func (d Docker) WithExecutable(executable Executable) Docker {
+	d.executable = executable
+	executable = command.WithContext(d.ctx, "docker", executable)
+
 	d.Image.Inspect.executable = executable
 	d.Image.Remove.executable = executable
 	d.Image.Tag.executable = executable
@@ -88,9 +143,14 @@ func (d Docker) WithExecutable(executable Executable) Docker {
 	d.Container.Run.executable = executable
 	d.Container.Run.inspect = d.Container.Inspect
 	d.Container.Stop.executable = executable
+	d.Container.Wait.executable = executable
 
 	d.Volume.Remove.executable = executable
 
+	d.Cache.Inspect.executable = executable
+	d.Cache.Remove.volumeRemove = d.Volume.Remove
+	d.Cache.Remove.imageRemove.executable = executable
+
 	d.Pull.executable = executable
 
 	return d
@@ -164,6 +224,15 @@ func (r DockerImageTag) Execute(ref, target string) error {
 type DockerImageOCI struct {
 	client      DockerDaemonClient
 	nameOptions []name.Option
+	retry       RetryPolicy
+	ctx         context.Context
+}
+
+// WithRetryPolicy retries reading the image from the daemon when it fails
+// with a transient error, such as a connection reset.
+func (r DockerImageOCI) WithRetryPolicy(policy RetryPolicy) DockerImageOCI {
+	r.retry = policy
+	return r
 }
 
 func (r DockerImageOCI) WithNameOptions(opts ...name.Option) DockerImageOCI {
@@ -190,7 +259,16 @@ func (r DockerImageOCI) Execute(ref string) (v1.Image, error) {
 		return nil, err
 	}
 
-	return daemon.Image(nameRef, daemon.WithClient(r.client))
+	var image v1.Image
+	err = r.retry.do(r.ctx, func() error {
+		image, err = daemon.Image(nameRef, daemon.WithClient(r.client))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
 }
 
 type DockerContainerRun struct {
@@ -210,6 +288,8 @@ type DockerContainerRun struct {
 	volumes      []string
 	readOnly     bool
 	mounts       []string
+	bindings     []Binding
+	cleanup      CleanupRegistrar
 }
 
 func (r DockerContainerRun) WithEnv(env map[string]string) DockerContainerRun {
@@ -283,7 +363,59 @@ func (r DockerContainerRun) WithMounts(mounts ...string) DockerContainerRun {
 	return r
 }
 
+// WithBindings gives the service bindings to the container. They are written
+// to a temporary directory, mounted at /bindings with SERVICE_BINDING_ROOT
+// pointing at it. The directory outlives the run, so bindings require a
+// registrar given to WithCleanup, which removes it. It is also removed if the
+// container fails to start.
+func (r DockerContainerRun) WithBindings(bindings ...Binding) DockerContainerRun {
+	r.bindings = append(slices.Clone(r.bindings), bindings...)
+	return r
+}
+
+// WithCleanup registers the removal of the resources created for the
+// container, such as its bindings, with the given registrar.
+func (r DockerContainerRun) WithCleanup(registrar CleanupRegistrar) DockerContainerRun {
+	r.cleanup = registrar
+	return r
+}
+
 func (r DockerContainerRun) Execute(imageID string) (Container, error) {
+	if len(r.bindings) > 0 {
+		if r.cleanup == nil {
+			return Container{}, fmt.Errorf("failed to run docker container: bindings require a cleanup registrar, use WithCleanup to remove them")
+		}
+
+		dir, err := materializeBindings(r.bindings)
+		if err != nil {
+			return Container{}, fmt.Errorf("failed to run docker container: %w", err)
+		}
+
+		removeBindings := func() {
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to clean up bindings: %s\n", err)
+			}
+		}
+		r.cleanup.Cleanup(removeBindings)
+
+		env := map[string]string{}
+		for key, value := range r.env {
+			env[key] = value
+		}
+		env["SERVICE_BINDING_ROOT"] = launchBindingRoot
+
+		r.env = env
+		r.volumes = append(slices.Clone(r.volumes), fmt.Sprintf("%s:%s:ro", dir, launchBindingRoot))
+		r.bindings = nil
+
+		container, err := r.Execute(imageID)
+		if err != nil {
+			removeBindings()
+		}
+
+		return container, err
+	}
+
 	args := []string{"container", "run", "--detach"}
 
 	if r.tty {
@@ -438,6 +570,73 @@ func (l DockerContainerLogs) Execute(containerID string) (fmt.Stringer, error) {
 	return output, nil
 }
 
+// Follow streams the logs of the container as it writes them, with `docker
+// container logs --follow`. The stream ends when the container stops. When
+// the context is done, docker is killed and the stream is closed with the
+// error of the context. Closing the stream kills docker too.
+func (l DockerContainerLogs) Follow(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to follow docker container logs: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	reader, writer := io.Pipe()
+	stream := &logStream{
+		PipeReader: reader,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		stderr := bytes.NewBuffer(nil)
+		err := command.WithContext(ctx, "docker", l.executable).Execute(pexec.Execution{
+			Args:   []string{"container", "logs", "--follow", containerID},
+			Stdout: writer,
+			Stderr: io.MultiWriter(writer, stderr),
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to follow docker container logs: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		_ = writer.CloseWithError(err)
+		stream.stop()
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = writer.CloseWithError(ctx.Err())
+		case <-stream.done:
+		}
+	}()
+
+	return stream, nil
+}
+
+// logStream is the stream of followed container logs. Its context is
+// cancelled once docker exits or the stream is closed.
+type logStream struct {
+	*io.PipeReader
+
+	cancel context.CancelFunc
+	once   sync.Once
+	done   chan struct{}
+}
+
+func (s *logStream) Close() error {
+	s.stop()
+	return s.PipeReader.Close()
+}
+
+func (s *logStream) stop() {
+	s.once.Do(func() {
+		close(s.done)
+		s.cancel()
+	})
+}
+
 type DockerContainerStop struct {
 	executable Executable
 }
@@ -456,6 +655,31 @@ func (s DockerContainerStop) Execute(containerID string) error {
 	return nil
 }
 
+type DockerContainerWait struct {
+	executable Executable
+}
+
+// Execute blocks until the container stops and returns its exit code.
+func (w DockerContainerWait) Execute(containerID string) (int, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	err := w.executable.Execute(pexec.Execution{
+		Args:   []string{"container", "wait", containerID},
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to wait for docker container: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to wait for docker container: %w", err)
+	}
+
+	return exitCode, nil
+}
+
 type DockerContainerCopy struct {
 	executable Executable
 }
@@ -543,19 +767,217 @@ func (r DockerVolumeRemove) Execute(volumes []string) error {
 	return nil
 }
 
-type DockerPull struct {
+// DockerCacheInspect reads what the lifecycle stored in a pack build cache.
+// Volume caches are copied out of their volume through a throwaway container,
+// bind caches are read from their host directory and image caches are
+// fetched from their registry.
+type DockerCacheInspect struct {
 	executable Executable
+	image      string
+	cleanup    CleanupRegistrar
 }
 
-func (p DockerPull) Execute(image string) error {
+// WithImage sets the image of the container volume caches are copied out
+// through. It must have tar, and defaults to busybox:latest.
+func (i DockerCacheInspect) WithImage(image string) DockerCacheInspect {
+	i.image = image
+	return i
+}
+
+// WithCleanup registers the removal of the copies of volume caches with the
+// given registrar, usually the *testing.T of the test inspecting them.
+// Without one, the cached layers are read into memory and the copies are
+// removed straight away.
+func (i DockerCacheInspect) WithCleanup(registrar CleanupRegistrar) DockerCacheInspect {
+	i.cleanup = registrar
+	return i
+}
+
+func (i DockerCacheInspect) Execute(cache Cache) (CacheContents, error) {
+	switch cache.Format {
+	case CacheFormatBind:
+		contents, err := readCommittedCache(cache, filepath.Join(cache.Source, "committed"), false)
+		if err != nil {
+			return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+		}
+
+		return contents, nil
+
+	case CacheFormatVolume:
+		return i.inspectVolume(cache)
+
+	case CacheFormatImage:
+		return inspectImageCache(cache)
+
+	default:
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: unknown format %q", cache.Format)
+	}
+}
+
+func (i DockerCacheInspect) inspectVolume(cache Cache) (CacheContents, error) {
+	dir, err := os.MkdirTemp("", "cache")
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	removeDir := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up cache copy: %s\n", err)
+		}
+	}
+
+	if i.cleanup != nil {
+		i.cleanup.Cleanup(removeDir)
+	} else {
+		defer removeDir()
+	}
+
+	archive, err := os.Create(filepath.Join(dir, "cache.tar"))
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+	defer func() {
+		_ = archive.Close()
+		if err := os.Remove(archive.Name()); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up cache copy: %s\n", err)
+		}
+	}()
 
 	stderr := bytes.NewBuffer(nil)
-	err := p.executable.Execute(pexec.Execution{
-		Args:   []string{"pull", image},
+	err = i.executable.Execute(pexec.Execution{
+		Args: []string{
+			"container", "run", "--rm",
+			"--volume", fmt.Sprintf("%s:/cache:ro", cache.Name),
+			"--entrypoint", "tar",
+			i.image,
+			"-c", "-C", "/cache/committed", ".",
+		},
+		Stdout: archive,
 		Stderr: stderr,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to pull docker image: %w: %s", err, strings.TrimSpace(stderr.String()))
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: failed to copy volume %s: %w: %s", cache.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	committed := filepath.Join(dir, "committed")
+	err = vacation.NewTarArchive(archive).Decompress(committed)
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	// Without a cleanup registrar the copy is removed before returning, so
+	// the cached layers are read into memory instead of from the copy.
+	contents, err := readCommittedCache(cache, committed, i.cleanup == nil)
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	return contents, nil
+}
+
+func inspectImageCache(cache Cache) (CacheContents, error) {
+	ref, err := name.ParseReference(cache.Name)
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	image, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	config, err := image.ConfigFile()
+	if err != nil {
+		return CacheContents{}, fmt.Errorf("failed to inspect cache: %w", err)
+	}
+
+	var buildpacks []ImageBuildpackMetadata
+	if label, ok := config.Config.Labels[cacheMetadataName]; ok {
+		buildpacks, err = parseBuildpackMetadata([]byte(label))
+		if err != nil {
+			return CacheContents{}, fmt.Errorf("failed to inspect cache: failed to parse cache metadata: %w", err)
+		}
+	}
+
+	return CacheContents{
+		Cache:      cache,
+		Image:      image,
+		Buildpacks: buildpacks,
+	}, nil
+}
+
+// DockerCacheRemove removes pack build caches: volume caches with `docker
+// volume rm`, image caches with `docker image remove` and the host directories
+// of bind caches.
+type DockerCacheRemove struct {
+	volumeRemove DockerVolumeRemove
+	imageRemove  DockerImageRemove
+}
+
+func (r DockerCacheRemove) Execute(caches []Cache) error {
+	var volumes []string
+	for _, cache := range caches {
+		switch cache.Format {
+		case CacheFormatVolume:
+			volumes = append(volumes, cache.Name)
+
+		case CacheFormatImage:
+			err := r.imageRemove.Execute(cache.Name)
+			if err != nil {
+				return fmt.Errorf("failed to remove cache: %w", err)
+			}
+
+		case CacheFormatBind:
+			err := os.RemoveAll(cache.Source)
+			if err != nil {
+				return fmt.Errorf("failed to remove cache: %w", err)
+			}
+		}
+	}
+
+	if len(volumes) > 0 {
+		err := r.volumeRemove.Execute(volumes)
+		if err != nil {
+			return fmt.Errorf("failed to remove cache: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type DockerPull struct {
+	executable Executable
+	retry      RetryPolicy
+	ctx        context.Context
+}
+
+// WithRetryPolicy retries pulls that fail with a transient error, such as a
+// registry 5xx, as classified from the stderr of docker.
+func (p DockerPull) WithRetryPolicy(policy RetryPolicy) DockerPull {
+	p.retry = policy
+	return p
+}
+
+func (p DockerPull) Execute(image string) error {
+	err := p.retry.do(p.ctx, func() error {
+		stderr := bytes.NewBuffer(nil)
+		err := p.executable.Execute(pexec.Execution{
+			Args:   []string{"pull", image},
+			Stderr: stderr,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull docker image: %w", err)
 	}
 
 	return nil
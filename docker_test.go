@@ -1,21 +1,32 @@
 package occam_test
 
 import (
+	"archive/tar"
 	"bytes"
 	ctx "context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/moby/moby/api/types/image"
 	"github.com/moby/moby/client"
 	name "github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/paketo-buildpacks/occam"
 	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/occam/matchers"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/sclevine/spec"
 
@@ -24,7 +35,8 @@ import (
 
 func testDocker(t *testing.T, context spec.G, it spec.S) {
 	var (
-		Expect = NewWithT(t).Expect
+		Expect     = NewWithT(t).Expect
+		Eventually = NewWithT(t).Eventually
 
 		executable *fakes.Executable
 		docker     occam.Docker
@@ -88,6 +100,24 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 				}))
 			})
 
+			context("when the image has no lifecycle metadata", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stdout, `[{"Id": "some-image-id", "Config": {"Labels": {"some-label": "some-value"}}}]`)
+						return nil
+					}
+				})
+
+				it("returns the image without buildpacks", func() {
+					image, err := docker.Image.Inspect.Execute("some-buildpack:latest")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(image).To(Equal(occam.Image{
+						ID:     "some-image-id",
+						Labels: map[string]string{"some-label": "some-value"},
+					}))
+				})
+			})
+
 			context("failure cases", func() {
 				context("when the executable fails", func() {
 					it.Before(func() {
@@ -686,6 +716,43 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 				})
 			})
 
+			context("when given optional bindings", func() {
+				it("mounts the bindings and removes them on cleanup", func() {
+					registrar := &cleanupRegistrar{}
+
+					container, err := docker.Container.Run.
+						WithEnv(map[string]string{"PORT": "8080"}).
+						WithBindings(occam.NewBinding("some-binding", "some-type").WithSecret("some-key", "some-value")).
+						WithCleanup(registrar).
+						Execute("some-image-id")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(container).To(Equal(occam.Container{
+						ID: "some-container-id",
+					}))
+
+					Expect(executeArgs).To(HaveLen(2))
+					Expect(executeArgs[0]).To(HaveLen(10))
+
+					bindingsDir := strings.TrimSuffix(executeArgs[0][8], ":/bindings:ro")
+					Expect(executeArgs[0]).To(Equal([]string{
+						"container", "run",
+						"--detach",
+						"--env", "PORT=8080",
+						"--env", "SERVICE_BINDING_ROOT=/bindings",
+						"--volume", fmt.Sprintf("%s:/bindings:ro", bindingsDir),
+						"some-image-id",
+					}))
+
+					content, err := os.ReadFile(filepath.Join(bindingsDir, "some-binding", "some-key"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal("some-value"))
+
+					Expect(registrar.funcs).To(HaveLen(1))
+					registrar.funcs[0]()
+					Expect(bindingsDir).NotTo(BeADirectory())
+				})
+			})
+
 			context("failure cases", func() {
 				context("when the executable fails", func() {
 					it.Before(func() {
@@ -700,6 +767,16 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 						Expect(err).To(MatchError("failed to run docker container: exit status 1: Unable to find image 'some-image-id' locally"))
 					})
 				})
+
+				context("when given bindings without a cleanup registrar", func() {
+					it("returns an error without running the container", func() {
+						_, err := docker.Container.Run.
+							WithBindings(occam.NewBinding("some-binding", "some-type")).
+							Execute("some-image-id")
+						Expect(err).To(MatchError("failed to run docker container: bindings require a cleanup registrar, use WithCleanup to remove them"))
+						Expect(executeArgs).To(BeEmpty())
+					})
+				})
 			})
 		})
 
@@ -878,6 +955,109 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 					})
 				})
 			})
+
+			context("Follow", func() {
+				it("streams the logs until the container stops", func() {
+					stream, err := docker.Container.Logs.Follow(ctx.Background(), "some-container-id")
+					Expect(err).NotTo(HaveOccurred())
+
+					logs, err := io.ReadAll(stream)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(logs)).To(ContainSubstring("on stdout\n"))
+					Expect(string(logs)).To(ContainSubstring("on stderr\n"))
+					Expect(stream.Close()).To(Succeed())
+
+					Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+						"container", "logs", "--follow", "some-container-id",
+					}))
+				})
+
+				context("when the context is cancelled", func() {
+					var release chan struct{}
+
+					it.Before(func() {
+						release = make(chan struct{})
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stdout, "some log line")
+							<-release
+							return nil
+						}
+					})
+
+					it.After(func() {
+						close(release)
+					})
+
+					it("ends the stream with the context error", func() {
+						context, cancel := ctx.WithCancel(ctx.Background())
+
+						stream, err := docker.Container.Logs.Follow(context, "some-container-id")
+						Expect(err).NotTo(HaveOccurred())
+
+						line := make([]byte, len("some log line\n"))
+						_, err = io.ReadFull(stream, line)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(line)).To(Equal("some log line\n"))
+
+						cancel()
+
+						_, err = io.ReadAll(stream)
+						Expect(err).To(MatchError(ctx.Canceled))
+					})
+				})
+
+				context("when the stream is closed", func() {
+					it("kills docker without the context being cancelled", func() {
+						killed := make(chan error, 1)
+						docker = docker.WithExecutable(contextExecutable(func(c ctx.Context, execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stdout, "some log line")
+							<-c.Done()
+							killed <- c.Err()
+							return c.Err()
+						}))
+
+						stream, err := docker.Container.Logs.Follow(ctx.Background(), "some-container-id")
+						Expect(err).NotTo(HaveOccurred())
+
+						line := make([]byte, len("some log line\n"))
+						_, err = io.ReadFull(stream, line)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(stream.Close()).To(Succeed())
+						Eventually(killed).Should(Receive(MatchError(ctx.Canceled)))
+					})
+				})
+
+				context("failure cases", func() {
+					context("when the context is already done", func() {
+						it("returns an error", func() {
+							context, cancel := ctx.WithCancel(ctx.Background())
+							cancel()
+
+							_, err := docker.Container.Logs.Follow(context, "some-container-id")
+							Expect(err).To(MatchError("failed to follow docker container logs: context canceled"))
+							Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+						})
+					})
+
+					context("when the executable fails", func() {
+						it.Before(func() {
+							executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+								_, _ = fmt.Fprintln(execution.Stderr, "Error: No such container: some-container-id")
+								return errors.New("exit status 1")
+							}
+						})
+
+						it("ends the stream with an error", func() {
+							stream, err := docker.Container.Logs.Follow(ctx.Background(), "some-container-id")
+							Expect(err).NotTo(HaveOccurred())
+
+							_, err = io.ReadAll(stream)
+							Expect(err).To(MatchError("failed to follow docker container logs: exit status 1: Error: No such container: some-container-id"))
+						})
+					})
+				})
+			})
 		})
 
 		context("Stop", func() {
@@ -906,6 +1086,55 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("Wait", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stdout, "100")
+					return nil
+				}
+			})
+
+			it("waits for the container and returns its exit code", func() {
+				exitCode, err := docker.Container.Wait.Execute("some-container-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exitCode).To(Equal(100))
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"container", "wait", "some-container-id",
+				}))
+			})
+
+			context("failure cases", func() {
+				context("when the executable fails", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stderr, "Error: No such container: some-container-id")
+							return errors.New("exit status 1")
+						}
+					})
+
+					it("returns an error", func() {
+						_, err := docker.Container.Wait.Execute("some-container-id")
+						Expect(err).To(MatchError("failed to wait for docker container: exit status 1: Error: No such container: some-container-id"))
+					})
+				})
+
+				context("when the output is not an exit code", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stdout, "not-a-number")
+							return nil
+						}
+					})
+
+					it("returns an error", func() {
+						_, err := docker.Container.Wait.Execute("some-container-id")
+						Expect(err).To(MatchError(ContainSubstring("failed to wait for docker container: strconv.Atoi")))
+					})
+				})
+			})
+		})
+
 		context("Copy", func() {
 			it("will execute 'docker container cp SOURCE DEST'", func() {
 				err := docker.Container.Copy.Execute("source/path", "dest-container:/path")
@@ -1083,6 +1312,235 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("Cache", func() {
+		context("Inspect", func() {
+			var (
+				layer    []byte
+				metadata string
+			)
+
+			it.Before(func() {
+				layer = tarFiles(map[string]string{
+					"layers/some-buildpack/some-layer/some-file": "some-content",
+				})
+				metadata = `{"buildpacks": [{"key": "some-buildpack", "layers": {"some-layer": {"sha": "some-sha", "cache": true, "data": {"some-key": "some-value"}}}}]}`
+			})
+
+			context("when given a bind cache", func() {
+				var source string
+
+				it.Before(func() {
+					source = t.TempDir()
+					Expect(os.MkdirAll(filepath.Join(source, "committed"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(source, "committed", "sha256:some-diff-id.tar"), layer, 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(source, "committed", "io.buildpacks.lifecycle.cache.metadata"), []byte(metadata), 0600)).To(Succeed())
+				})
+
+				it("returns the cached files and metadata", func() {
+					contents, err := docker.Cache.Inspect.Execute(occam.NewBindCache(occam.CacheTypeBuild, source))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(contents.Cache).To(Equal(occam.NewBindCache(occam.CacheTypeBuild, source)))
+					Expect(contents.Image).To(matchers.HaveFileWithContent("/layers/some-buildpack/some-layer/some-file", "some-content"))
+
+					buildpack, err := contents.BuildpackForKey("some-buildpack")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(buildpack.Layers["some-layer"]).To(Equal(occam.ImageBuildpackMetadataLayer{
+						SHA:      "some-sha",
+						Cache:    true,
+						Metadata: map[string]interface{}{"some-key": "some-value"},
+					}))
+					Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when given a volume cache", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, err := execution.Stdout.Write(tarFiles(map[string]string{
+							"./sha256:some-diff-id.tar":                string(layer),
+							"./io.buildpacks.lifecycle.cache.metadata": metadata,
+						}))
+						return err
+					}
+				})
+
+				it("copies the volume out through a container", func() {
+					tmpDir := t.TempDir()
+					t.Setenv("TMPDIR", tmpDir)
+
+					contents, err := docker.Cache.Inspect.
+						WithImage("some-image").
+						WithCleanup(t).
+						Execute(occam.NewVolumeCache(occam.CacheTypeBuild, "some-volume"))
+					Expect(err).NotTo(HaveOccurred())
+
+					archives, err := filepath.Glob(filepath.Join(tmpDir, "*", "cache.tar"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(archives).To(BeEmpty())
+
+					Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+						"container", "run", "--rm",
+						"--volume", "some-volume:/cache:ro",
+						"--entrypoint", "tar",
+						"some-image",
+						"-c", "-C", "/cache/committed", ".",
+					}))
+
+					Expect(contents.Image).To(matchers.HaveFile("/layers/some-buildpack/some-layer/some-file"))
+					Expect(contents.Buildpacks).To(HaveLen(1))
+				})
+
+				context("when not given a cleanup registrar", func() {
+					it("reads the cached layers and removes the copy", func() {
+						tmpDir := t.TempDir()
+						t.Setenv("TMPDIR", tmpDir)
+
+						contents, err := docker.Cache.Inspect.Execute(occam.NewVolumeCache(occam.CacheTypeBuild, "some-volume"))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(tmpDir).To(BeADirectory())
+						Expect(os.ReadDir(tmpDir)).To(BeEmpty())
+						Expect(contents.Image).To(matchers.HaveFileWithContent("/layers/some-buildpack/some-layer/some-file", "some-content"))
+					})
+				})
+
+				context("when the volume holds no metadata", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, err := execution.Stdout.Write(tarFiles(map[string]string{
+								"./sha256:some-diff-id.tar": string(layer),
+							}))
+							return err
+						}
+					})
+
+					it("returns the cached files", func() {
+						contents, err := docker.Cache.Inspect.
+							WithCleanup(t).
+							Execute(occam.NewVolumeCache(occam.CacheTypeLaunch, "some-volume"))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(contents.Image).To(matchers.HaveFile("/layers/some-buildpack/some-layer/some-file"))
+						Expect(contents.Buildpacks).To(BeEmpty())
+					})
+				})
+			})
+
+			context("when given an image cache", func() {
+				it("fetches the image from the registry", func() {
+					registry, err := occam.NewLocalRegistry().WithCleanup(t).Start()
+					Expect(err).NotTo(HaveOccurred())
+
+					image, err := mutate.AppendLayers(empty.Image, static.NewLayer(layer, types.DockerLayer))
+					Expect(err).NotTo(HaveOccurred())
+
+					image, err = mutate.Config(image, v1.Config{
+						Labels: map[string]string{"io.buildpacks.lifecycle.cache.metadata": metadata},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					ref, err := registry.Push("some-cache:latest", image)
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := docker.Cache.Inspect.Execute(occam.NewImageCache(ref))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(contents.Image).To(matchers.HaveFileWithContent("/layers/some-buildpack/some-layer/some-file", "some-content"))
+					Expect(contents.Buildpacks).To(HaveLen(1))
+					Expect(contents.Buildpacks[0].Key).To(Equal("some-buildpack"))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the bind cache has no committed directory", func() {
+					it("returns an error", func() {
+						_, err := docker.Cache.Inspect.Execute(occam.NewBindCache(occam.CacheTypeBuild, t.TempDir()))
+						Expect(err).To(MatchError(ContainSubstring("failed to inspect cache")))
+					})
+				})
+
+				context("when the volume cannot be copied", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stderr, "tar: can't change directory to '/cache/committed'")
+							return errors.New("exit status 1")
+						}
+					})
+
+					it("returns an error and removes the copy", func() {
+						tmpDir := t.TempDir()
+						t.Setenv("TMPDIR", tmpDir)
+
+						_, err := docker.Cache.Inspect.Execute(occam.NewVolumeCache(occam.CacheTypeBuild, "some-volume"))
+						Expect(err).To(MatchError("failed to inspect cache: failed to copy volume some-volume: exit status 1: tar: can't change directory to '/cache/committed'"))
+						Expect(os.ReadDir(tmpDir)).To(BeEmpty())
+					})
+				})
+
+				context("when the cache metadata is malformed", func() {
+					it("returns an error", func() {
+						source := t.TempDir()
+						Expect(os.MkdirAll(filepath.Join(source, "committed"), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(source, "committed", "io.buildpacks.lifecycle.cache.metadata"), []byte("%%%"), 0600)).To(Succeed())
+
+						_, err := docker.Cache.Inspect.Execute(occam.NewBindCache(occam.CacheTypeBuild, source))
+						Expect(err).To(MatchError(ContainSubstring("failed to inspect cache: failed to parse cache metadata")))
+					})
+				})
+			})
+		})
+
+		context("Remove", func() {
+			var (
+				bindDir    string
+				executions []pexec.Execution
+			)
+
+			it.Before(func() {
+				bindDir = t.TempDir()
+				Expect(os.WriteFile(filepath.Join(bindDir, "some-file"), nil, 0600)).To(Succeed())
+
+				executions = nil
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					executions = append(executions, execution)
+					return nil
+				}
+			})
+
+			it("removes the volumes, images and directories of the caches", func() {
+				err := docker.Cache.Remove.Execute([]occam.Cache{
+					occam.NewVolumeCache(occam.CacheTypeBuild, "some-volume-name"),
+					occam.NewImageCache("some-registry/some-cache"),
+					occam.NewBindCache(occam.CacheTypeLaunch, bindDir),
+					occam.NewVolumeCache(occam.CacheTypeLaunch, "other-volume-name"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(2))
+				Expect(executions[0].Args).To(Equal([]string{"image", "remove", "some-registry/some-cache", "--force"}))
+				Expect(executions[1].Args).To(Equal([]string{"volume", "rm", "--force", "some-volume-name", "other-volume-name"}))
+				Expect(bindDir).NotTo(BeADirectory())
+			})
+
+			context("failure cases", func() {
+				context("when the volumes cannot be removed", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stderr, "Error: failed to remove volume")
+							return errors.New("exit status 1")
+						}
+					})
+
+					it("returns an error", func() {
+						err := docker.Cache.Remove.Execute(occam.DefaultCaches("some-app"))
+						Expect(err).To(MatchError("failed to remove cache: failed to remove docker volume: exit status 1: Error: failed to remove volume"))
+					})
+				})
+			})
+		})
+	})
+
 	context("Pull", func() {
 		it("will pull the given image", func() {
 			err := docker.Pull.Execute("some-image")
@@ -1093,6 +1551,52 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 			}))
 		})
 
+		context("WithRetryPolicy", func() {
+			it.Before(func() {
+				docker = docker.WithRetryPolicy(occam.RetryPolicy{Attempts: 3})
+			})
+
+			it("retries a pull that fails with a transient error", func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					if executable.ExecuteCall.CallCount == 1 {
+						_, _ = fmt.Fprintln(execution.Stderr, "Error response from daemon: received unexpected HTTP status: 503 Service Unavailable")
+						return errors.New("exit status 1")
+					}
+
+					return nil
+				}
+
+				err := docker.Pull.Execute("some-image")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(executable.ExecuteCall.CallCount).To(Equal(2))
+			})
+
+			it("reports every attempt when they all fail", func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintf(execution.Stderr, "Error response from daemon: Get \"https://registry/v2/\": net/http: TLS handshake timeout (attempt %d)\n", executable.ExecuteCall.CallCount)
+					return errors.New("exit status 1")
+				}
+
+				err := docker.Pull.Execute("some-image")
+				Expect(err).To(MatchError("failed to pull docker image: failed after 3 attempts:\n" +
+					"  attempt 1: exit status 1: Error response from daemon: Get \"https://registry/v2/\": net/http: TLS handshake timeout (attempt 1)\n" +
+					"  attempt 2: exit status 1: Error response from daemon: Get \"https://registry/v2/\": net/http: TLS handshake timeout (attempt 2)\n" +
+					"  attempt 3: exit status 1: Error response from daemon: Get \"https://registry/v2/\": net/http: TLS handshake timeout (attempt 3)"))
+				Expect(executable.ExecuteCall.CallCount).To(Equal(3))
+			})
+
+			it("does not retry a pull that fails with a permanent error", func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stderr, "Error response from daemon: manifest unknown")
+					return errors.New("exit status 1")
+				}
+
+				err := docker.Pull.Execute("some-image")
+				Expect(err).To(MatchError("failed to pull docker image: exit status 1: Error response from daemon: manifest unknown"))
+				Expect(executable.ExecuteCall.CallCount).To(Equal(1))
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the pull command fails", func() {
 				it.Before(func() {
@@ -1108,6 +1612,79 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 				})
 			})
 
+			context("when the pull does not finish before the deadline", func() {
+				var cancel ctx.CancelFunc
+
+				it.Before(func() {
+					var c ctx.Context
+					c, cancel = ctx.WithTimeout(ctx.Background(), 10*time.Millisecond)
+					docker = docker.WithContext(c)
+
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some partial output")
+						<-c.Done()
+						return errors.New("signal: killed")
+					}
+				})
+
+				it.After(func() {
+					cancel()
+				})
+
+				it("returns a timeout error with the output so far", func() {
+					err := docker.Pull.Execute("some-image")
+					Expect(err).To(MatchError("failed to pull docker image: command `docker pull some-image` timed out: context deadline exceeded: some partial output"))
+					Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+				})
+			})
+
 		})
 	})
 }
+
+// tarFiles returns a tarball holding the given files.
+func tarFiles(files map[string]string) []byte {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buffer := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buffer)
+	for _, name := range names {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = tw.Write([]byte(files[name]))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		panic(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// contextExecutable is an executable that is stopped through the context of
+// its execution, as the docker command is.
+type contextExecutable func(ctx.Context, pexec.Execution) error
+
+func (e contextExecutable) Execute(execution pexec.Execution) error {
+	return e(ctx.Background(), execution)
+}
+
+func (e contextExecutable) ExecuteContext(c ctx.Context, execution pexec.Execution) error {
+	return e(c, execution)
+}
@@ -0,0 +1,109 @@
+package occam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/paketo-buildpacks/freezer"
+	"github.com/paketo-buildpacks/freezer/github"
+)
+
+//go:generate faux --interface GitReleaseFetcher --output fakes/git_release_fetcher.go
+type GitReleaseFetcher interface {
+	freezer.GitReleaseFetcher
+	GetTag(org, repo, version string) (github.Release, error)
+}
+
+// GitHubReleaseService extends the freezer release service with the ability
+// to look up a specific release rather than only the latest one.
+type GitHubReleaseService struct {
+	github.ReleaseService
+
+	config github.Config
+}
+
+func NewGitHubReleaseService(config github.Config) GitHubReleaseService {
+	return GitHubReleaseService{
+		ReleaseService: github.NewReleaseService(config),
+		config:         config,
+	}
+}
+
+// GetTag returns the release for the given version. Paketo releases are
+// tagged with a leading "v", so that tag is tried before the bare version.
+func (s GitHubReleaseService) GetTag(org, repo, version string) (github.Release, error) {
+	var errs []error
+	for _, tag := range []string{fmt.Sprintf("v%s", version), version} {
+		release, err := s.getTag(org, repo, tag)
+		if err == nil {
+			return release, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return github.Release{}, fmt.Errorf("failed to find release %s of %s/%s: %v", version, org, repo, errs)
+}
+
+func (s GitHubReleaseService) getTag(org, repo, tag string) (github.Release, error) {
+	uri, err := url.Parse(s.config.Endpoint)
+	if err != nil {
+		return github.Release{}, err
+	}
+
+	uri.Path = fmt.Sprintf("/repos/%s/%s/releases/tags/%s", org, repo, tag)
+
+	req, err := http.NewRequest("GET", uri.String(), nil)
+	if err != nil {
+		return github.Release{}, err
+	}
+
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", s.config.Token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return github.Release{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return github.Release{}, fmt.Errorf("unexpected response status for tag %s: %s", tag, resp.Status)
+	}
+
+	var release github.Release
+	err = json.NewDecoder(resp.Body).Decode(&release)
+	if err != nil {
+		return github.Release{}, err
+	}
+
+	return release, nil
+}
+
+// pinnedReleaseFetcher answers requests for the latest release with a fixed
+// release so that the freezer remote fetcher downloads that release instead.
+// The downloads are hashed into the digest.
+type pinnedReleaseFetcher struct {
+	GitReleaseFetcher
+
+	release github.Release
+	digest  *sourceDigest
+}
+
+func (p pinnedReleaseFetcher) Get(org, repo string) (github.Release, error) {
+	return p.release, nil
+}
+
+func (p pinnedReleaseFetcher) GetReleaseAsset(asset github.ReleaseAsset) (io.ReadCloser, error) {
+	return p.digest.open(p.GitReleaseFetcher.GetReleaseAsset(asset))
+}
+
+func (p pinnedReleaseFetcher) GetReleaseTarball(url string) (io.ReadCloser, error) {
+	return p.digest.open(p.GitReleaseFetcher.GetReleaseTarball(url))
+}
@@ -3,6 +3,8 @@ package occam
 import (
 	"encoding/json"
 	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
 type Image struct {
@@ -36,6 +38,48 @@ func NewImageFromInspectOutput(output []byte) (Image, error) {
 		return Image{}, fmt.Errorf("failed to inspect docker image: %w", err)
 	}
 
+	return newImage(inspect[0].ID, inspect[0].Config.Labels)
+}
+
+// NewImageFromV1Image describes an image that is not in the Docker daemon,
+// such as one read from an OCI layout. Its ID is the digest of its config,
+// as with images in the daemon.
+func NewImageFromV1Image(image v1.Image) (Image, error) {
+	id, err := image.ConfigName()
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	config, err := image.ConfigFile()
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	return newImage(id.String(), config.Config.Labels)
+}
+
+func newImage(id string, labels map[string]string) (Image, error) {
+	var buildpacks []ImageBuildpackMetadata
+	// Images that were not built by the lifecycle, such as buildpack images,
+	// have no lifecycle metadata.
+	if label, ok := labels["io.buildpacks.lifecycle.metadata"]; ok {
+		var err error
+		buildpacks, err = parseBuildpackMetadata([]byte(label))
+		if err != nil {
+			return Image{}, fmt.Errorf("failed to inspect docker image: %w", err)
+		}
+	}
+
+	return Image{
+		ID:         id,
+		Buildpacks: buildpacks,
+		Labels:     labels,
+	}, nil
+}
+
+// parseBuildpackMetadata parses the layers of each buildpack from lifecycle
+// metadata, as found in the labels of app images and in caches.
+func parseBuildpackMetadata(content []byte) ([]ImageBuildpackMetadata, error) {
 	var metadata struct {
 		Buildpacks []struct {
 			Key    string `json:"key"`
@@ -48,9 +92,9 @@ func NewImageFromInspectOutput(output []byte) (Image, error) {
 			} `json:"layers"`
 		} `json:"buildpacks"`
 	}
-	err = json.Unmarshal([]byte(inspect[0].Config.Labels["io.buildpacks.lifecycle.metadata"]), &metadata)
+	err := json.Unmarshal(content, &metadata)
 	if err != nil {
-		return Image{}, fmt.Errorf("failed to inspect docker image: %w", err)
+		return nil, err
 	}
 
 	var buildpacks []ImageBuildpackMetadata
@@ -71,11 +115,7 @@ func NewImageFromInspectOutput(output []byte) (Image, error) {
 			Layers: layers})
 	}
 
-	return Image{
-		ID:         inspect[0].ID,
-		Buildpacks: buildpacks,
-		Labels:     inspect[0].Config.Labels,
-	}, nil
+	return buildpacks, nil
 }
 
 func (i Image) BuildpackForKey(key string) (ImageBuildpackMetadata, error) {
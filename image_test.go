@@ -3,6 +3,8 @@ package occam_test
 import (
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/paketo-buildpacks/occam"
 	"github.com/sclevine/spec"
 
@@ -12,6 +14,45 @@ import (
 func testImage(t *testing.T, context spec.G, it spec.S) {
 	var Expect = NewWithT(t).Expect
 
+	context("NewImageFromV1Image", func() {
+		it("describes the image from its config", func() {
+			image, err := random.Image(10, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			config, err := image.ConfigFile()
+			Expect(err).NotTo(HaveOccurred())
+
+			config.Config.Labels = map[string]string{
+				"io.buildpacks.lifecycle.metadata": `{"buildpacks": [{"key": "some-buildpack", "layers": {"some-layer": {"sha": "some-sha", "launch": true, "data": {"some-key": "some-value"}}}}]}`,
+			}
+
+			image, err = mutate.ConfigFile(image, config)
+			Expect(err).NotTo(HaveOccurred())
+
+			configName, err := image.ConfigName()
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := occam.NewImageFromV1Image(image)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(occam.Image{
+				ID: configName.String(),
+				Buildpacks: []occam.ImageBuildpackMetadata{
+					{
+						Key: "some-buildpack",
+						Layers: map[string]occam.ImageBuildpackMetadataLayer{
+							"some-layer": {
+								SHA:      "some-sha",
+								Launch:   true,
+								Metadata: map[string]interface{}{"some-key": "some-value"},
+							},
+						},
+					},
+				},
+				Labels: config.Config.Labels,
+			}))
+		})
+	})
+
 	context("BuildpackForKey", func() {
 		it("returns the Buildpack with the key", func() {
 			image := occam.Image{
@@ -12,14 +12,26 @@ func TestUnitOccam(t *testing.T) {
 	format.MaxLength = 0
 
 	suite := spec.New("occam", spec.Report(report.Terminal{}))
+	suite("Cache", testCache)
 	suite("CacheVolumeNames", testCacheVolumeNames)
 	suite("Container", testContainer)
 	suite("Docker", testDocker)
 	suite("Image", testImage)
+	suite("Lifecycle", testLifecycle)
 	suite("Pack", testPack)
+	suite("PackVersion", testPackVersion)
+	suite("ProjectDescriptor", testProjectDescriptor)
 	suite("RandomName", testRandomName)
+	suite("Registry", testRegistry)
+	suite("Retry", testRetry)
 	suite("Source", testSource)
+	suite("Binding", testBinding)
+	suite("BuilderCompatibility", testBuilderCompatibility)
+	suite("BuildpackPackage", testBuildpackPackage)
 	suite("BuildpackStore", testBuildpackStore)
+	suite("BuildpackStoreComposite", testBuildpackStoreComposite)
+	suite("BuildpackStoreLockfile", testBuildpackStoreLockfile)
+	suite("BuildpackStoreManifest", testBuildpackStoreManifest)
 	suite("ContainerStructureTest", testContainerStructureTest)
 	suite("Venom", testVenom)
 	suite("TestContainers", testTestContainers)
@@ -0,0 +1,186 @@
+// Package command runs executables that are stopped when a context is done.
+// It is shared by occam and its packagers.
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// waitDelay is how long a killed command is given to close its output, which
+// children it started may hold open.
+const waitDelay = 5 * time.Second
+
+type Executable interface {
+	Execute(pexec.Execution) error
+}
+
+type ContextExecutable interface {
+	ExecuteContext(context.Context, pexec.Execution) error
+}
+
+// Command is an executable on the $PATH, or at a path, like
+// pexec.Executable. Its executions are killed when their context is done.
+type Command struct {
+	name string
+}
+
+func New(name string) Command {
+	return Command{name: name}
+}
+
+func (c Command) Execute(execution pexec.Execution) error {
+	return c.ExecuteContext(context.Background(), execution)
+}
+
+func (c Command) ExecuteContext(ctx context.Context, execution pexec.Execution) error {
+	path := os.Getenv("PATH")
+	for _, variable := range execution.Env {
+		if strings.HasPrefix(variable, "PATH=") {
+			path = strings.TrimPrefix(variable, "PATH=")
+		}
+	}
+
+	executable, err := lookPath(c.name, path)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, executable, execution.Args...)
+	cmd.WaitDelay = waitDelay
+
+	if execution.Dir != "" {
+		cmd.Dir = execution.Dir
+	}
+
+	if len(execution.Env) > 0 {
+		cmd.Env = execution.Env
+	}
+
+	cmd.Stdout = execution.Stdout
+	cmd.Stderr = execution.Stderr
+	cmd.Stdin = execution.Stdin
+
+	return cmd.Run()
+}
+
+// lookPath finds the executable with the given name in the directories of
+// path, without changing the $PATH of the process as pexec does.
+func lookPath(name, path string) (string, error) {
+	if strings.Contains(name, string(filepath.Separator)) {
+		return name, nil
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+
+		candidate := filepath.Join(dir, name)
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return candidate, nil
+		}
+	}
+
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+// WithContext returns an executable that runs executions of the given one
+// until the context is done. Executables that cannot be stopped, such as
+// fakes, are run to completion before the context is checked. A nil context
+// leaves the executable unchanged.
+func WithContext(ctx context.Context, name string, executable Executable) Executable {
+	if ctx == nil {
+		return executable
+	}
+
+	return contextExecutable{
+		ctx:        ctx,
+		name:       name,
+		executable: executable,
+	}
+}
+
+type contextExecutable struct {
+	ctx        context.Context
+	name       string
+	executable Executable
+}
+
+func (e contextExecutable) Execute(execution pexec.Execution) error {
+	return e.ExecuteContext(context.Background(), execution)
+}
+
+// ExecuteContext runs the execution until either the context of the
+// executable or the given one is done.
+func (e contextExecutable) ExecuteContext(ctx context.Context, execution pexec.Execution) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(e.ctx, cancel)
+	defer stop()
+
+	if e.ctx.Err() != nil {
+		return e.timeout(execution, e.ctx.Err())
+	}
+
+	if ctx.Err() != nil {
+		return e.timeout(execution, ctx.Err())
+	}
+
+	var err error
+	if executable, ok := e.executable.(ContextExecutable); ok {
+		err = executable.ExecuteContext(ctx, execution)
+	} else {
+		err = e.executable.Execute(execution)
+	}
+
+	if err != nil {
+		// The merged context is cancelled asynchronously, so the context of
+		// the executable is checked on its own.
+		if e.ctx.Err() != nil {
+			return e.timeout(execution, e.ctx.Err())
+		}
+
+		if ctx.Err() != nil {
+			return e.timeout(execution, ctx.Err())
+		}
+	}
+
+	return err
+}
+
+func (e contextExecutable) timeout(execution pexec.Execution, err error) error {
+	return TimeoutError{
+		Command: strings.Join(append([]string{e.name}, execution.Args...), " "),
+		Err:     err,
+	}
+}
+
+// TimeoutError is returned when a command is stopped because its context is
+// done. It wraps the error of the context.
+type TimeoutError struct {
+	Command string
+	Err     error
+}
+
+func (e TimeoutError) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("command `%s` timed out: %s", e.Command, e.Err)
+	}
+
+	return fmt.Sprintf("command `%s` was cancelled: %s", e.Command, e.Err)
+}
+
+func (e TimeoutError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,176 @@
+package command_test
+
+import (
+	"bytes"
+	ctx "context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/occam/internal/command"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+)
+
+func testCommand(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Command", func() {
+		var (
+			stdout *bytes.Buffer
+			stderr *bytes.Buffer
+		)
+
+		it.Before(func() {
+			stdout = bytes.NewBuffer(nil)
+			stderr = bytes.NewBuffer(nil)
+		})
+
+		it("runs the executable", func() {
+			err := command.New("sh").Execute(pexec.Execution{
+				Args:   []string{"-c", "echo some-output; echo some-error >&2; pwd"},
+				Dir:    t.TempDir(),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(HavePrefix("some-output\n"))
+			Expect(stderr.String()).To(Equal("some-error\n"))
+		})
+
+		it("finds the executable on the $PATH of the execution", func() {
+			dir := t.TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "some-executable"), []byte("#!/bin/sh\necho some-executable \"$@\"\n"), 0755)).To(Succeed())
+
+			shell, err := exec.LookPath("sh")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.New("some-executable").Execute(pexec.Execution{
+				Args:   []string{"some-arg"},
+				Env:    []string{"PATH=" + dir + string(os.PathListSeparator) + filepath.Dir(shell)},
+				Stdout: stdout,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(Equal("some-executable some-arg\n"))
+			Expect(os.Getenv("PATH")).NotTo(ContainSubstring(dir))
+		})
+
+		context("when the context is done", func() {
+			it("kills the executable and keeps its output so far", func() {
+				c, cancel := ctx.WithTimeout(ctx.Background(), 200*time.Millisecond)
+				defer cancel()
+
+				start := time.Now()
+				err := command.New("sh").ExecuteContext(c, pexec.Execution{
+					Args:   []string{"-c", "echo some-partial-output; exec sleep 10"},
+					Stdout: stdout,
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+				Expect(stdout.String()).To(Equal("some-partial-output\n"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the executable cannot be found", func() {
+				it("returns an error", func() {
+					err := command.New("no-such-executable").Execute(pexec.Execution{
+						Env: []string{"PATH=" + t.TempDir()},
+					})
+					Expect(err).To(MatchError(exec.ErrNotFound))
+				})
+			})
+		})
+	})
+
+	context("WithContext", func() {
+		var executable *fakes.Executable
+
+		it.Before(func() {
+			executable = &fakes.Executable{}
+		})
+
+		it("returns the executable when there is no context", func() {
+			Expect(command.WithContext(nil, "some-executable", executable)).To(BeIdenticalTo(executable))
+		})
+
+		it("runs the executable", func() {
+			err := command.WithContext(ctx.Background(), "some-executable", executable).Execute(pexec.Execution{
+				Args: []string{"some-arg"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"some-arg"}))
+		})
+
+		it("returns the errors of the executable", func() {
+			executable.ExecuteCall.Returns.Error = errors.New("some error")
+
+			err := command.WithContext(ctx.Background(), "some-executable", executable).Execute(pexec.Execution{})
+			Expect(err).To(MatchError("some error"))
+		})
+
+		it("stops commands that support a context", func() {
+			c, cancel := ctx.WithTimeout(ctx.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			err := command.WithContext(c, "sh", command.New("sh")).Execute(pexec.Execution{
+				Args: []string{"-c", "exec sleep 10"},
+			})
+			Expect(err).To(MatchError("command `sh -c exec sleep 10` timed out: context deadline exceeded"))
+			Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+
+			var timeoutErr command.TimeoutError
+			Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+			Expect(timeoutErr.Command).To(Equal("sh -c exec sleep 10"))
+		})
+
+		context("when the context is cancelled while the executable runs", func() {
+			it("returns a cancellation error", func() {
+				c, cancel := ctx.WithCancel(ctx.Background())
+				defer cancel()
+
+				executable.ExecuteCall.Stub = func(pexec.Execution) error {
+					cancel()
+					return errors.New("signal: killed")
+				}
+
+				err := command.WithContext(c, "some-executable", executable).Execute(pexec.Execution{
+					Args: []string{"some-arg"},
+				})
+				Expect(err).To(MatchError("command `some-executable some-arg` was cancelled: context canceled"))
+				Expect(errors.Is(err, ctx.Canceled)).To(BeTrue())
+			})
+		})
+
+		context("when the context is done before the executable runs", func() {
+			it("does not run it", func() {
+				c, cancel := ctx.WithCancel(ctx.Background())
+				cancel()
+
+				err := command.WithContext(c, "some-executable", executable).Execute(pexec.Execution{})
+				Expect(err).To(MatchError("command `some-executable` was cancelled: context canceled"))
+				Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the executable succeeds as the context is done", func() {
+			it("returns no error", func() {
+				c, cancel := ctx.WithCancel(ctx.Background())
+				defer cancel()
+
+				executable.ExecuteCall.Stub = func(pexec.Execution) error {
+					cancel()
+					return nil
+				}
+
+				err := command.WithContext(c, "some-executable", executable).Execute(pexec.Execution{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+}
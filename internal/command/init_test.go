@@ -0,0 +1,14 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitCommand(t *testing.T) {
+	suite := spec.New("command", spec.Report(report.Terminal{}))
+	suite("Command", testCommand)
+	suite.Run(t)
+}
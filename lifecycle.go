@@ -0,0 +1,386 @@
+package occam
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+)
+
+// LifecyclePhase is a phase of the lifecycle run by Lifecycle.
+type LifecyclePhase string
+
+const (
+	LifecycleAnalyzer LifecyclePhase = "analyzer"
+	LifecycleDetector LifecyclePhase = "detector"
+	LifecycleBuilder  LifecyclePhase = "builder"
+	LifecycleExporter LifecyclePhase = "exporter"
+)
+
+// LifecycleBuildpack is a buildpack listed in the group.toml or plan.toml
+// written by the detector.
+type LifecycleBuildpack struct {
+	ID      string `toml:"id"`
+	Version string `toml:"version"`
+	API     string `toml:"api"`
+}
+
+// LifecyclePlanEntry is an entry of the build plan written by the detector.
+type LifecyclePlanEntry struct {
+	Providers []LifecycleBuildpack       `toml:"providers"`
+	Requires  []LifecyclePlanRequirement `toml:"requires"`
+}
+
+type LifecyclePlanRequirement struct {
+	Name     string                 `toml:"name"`
+	Metadata map[string]interface{} `toml:"metadata"`
+}
+
+// LifecycleResult describes a run of the lifecycle phases.
+//
+// ExitCodes holds the exit code of each phase that ran. Phases run in order
+// and stop at the first one that fails, so later phases are missing from it.
+// Dir is a temporary directory holding the layers, the app workspace and the
+// OCI layout written by the exporter; it should be removed once the test is
+// done with it.
+type LifecycleResult struct {
+	ExitCodes map[LifecyclePhase]int
+	Group     []LifecycleBuildpack
+	Plan      []LifecyclePlanEntry
+	Dir       string
+	LayersDir string
+	LayoutDir string
+	Logs      string
+}
+
+// Lifecycle runs lifecycle phases of a builder directly, inside a single
+// container started with `docker container run`, without `pack build`. It is
+// meant for tests of detect and build logic that do not need a full app
+// image.
+type Lifecycle struct {
+	docker      Docker
+	phases      []LifecyclePhase
+	layers      string
+	env         map[string]string
+	runImage    string
+	platformAPI string
+	tempDir     func(dir, pattern string) (string, error)
+}
+
+func NewLifecycle() Lifecycle {
+	return Lifecycle{
+		docker:      NewDocker(),
+		phases:      []LifecyclePhase{LifecycleDetector, LifecycleBuilder},
+		platformAPI: "0.12",
+		tempDir:     os.MkdirTemp,
+	}
+}
+
+func (l Lifecycle) WithDocker(docker Docker) Lifecycle {
+	l.docker = docker
+	return l
+}
+
+// WithPhases sets the phases to run. It defaults to the detector and the
+// builder.
+func (l Lifecycle) WithPhases(phases ...LifecyclePhase) Lifecycle {
+	l.phases = phases
+	return l
+}
+
+// WithLayers seeds the layers directory, for example with the LayersDir of a
+// previous run, so that a phase can be run in isolation.
+func (l Lifecycle) WithLayers(dir string) Lifecycle {
+	l.layers = dir
+	return l
+}
+
+// WithEnv sets the build-time environment variables given to the buildpacks
+// through the platform directory.
+func (l Lifecycle) WithEnv(env map[string]string) Lifecycle {
+	l.env = env
+	return l
+}
+
+// WithRunImage sets the run image the exporter exports onto. It must be
+// available in the OCI layout, under <LayoutDir>/<registry>/<repository>/<tag>.
+// The analyzer, which runs before the other phases when exporting, records it
+// in the analyzed.toml the exporter reads.
+func (l Lifecycle) WithRunImage(runImage string) Lifecycle {
+	l.runImage = runImage
+	return l
+}
+
+// WithPlatformAPI sets the platform API the phases are run with. It defaults
+// to 0.12.
+func (l Lifecycle) WithPlatformAPI(platformAPI string) Lifecycle {
+	l.platformAPI = platformAPI
+	return l
+}
+
+func (l Lifecycle) WithTempDir(tempDir func(string, string) (string, error)) Lifecycle {
+	l.tempDir = tempDir
+	return l
+}
+
+func (l Lifecycle) Execute(builder, source string) (LifecycleResult, error) {
+	if len(l.phases) == 0 {
+		return LifecycleResult{}, errors.New("failed to run lifecycle: no phases given")
+	}
+
+	for _, phase := range l.phases {
+		switch phase {
+		case LifecycleAnalyzer, LifecycleDetector, LifecycleBuilder:
+		case LifecycleExporter:
+			if l.runImage == "" {
+				return LifecycleResult{}, errors.New("failed to run lifecycle: the exporter requires a run image")
+			}
+		default:
+			return LifecycleResult{}, fmt.Errorf("failed to run lifecycle: unknown phase %q", phase)
+		}
+	}
+
+	dir, err := l.tempDir("", "lifecycle")
+	if err != nil {
+		return LifecycleResult{}, fmt.Errorf("failed to run lifecycle: %w", err)
+	}
+
+	result := LifecycleResult{
+		ExitCodes: map[LifecyclePhase]int{},
+		Dir:       dir,
+		LayersDir: filepath.Join(dir, "layers"),
+		LayoutDir: filepath.Join(dir, "layout"),
+	}
+
+	// The exporter reads the run image from the analyzed.toml written by the
+	// analyzer, which has to run first.
+	if slices.Contains(l.phases, LifecycleExporter) && !slices.Contains(l.phases, LifecycleAnalyzer) {
+		l.phases = append([]LifecyclePhase{LifecycleAnalyzer}, l.phases...)
+	}
+
+	err = l.prepare(dir, source)
+	if err != nil {
+		return result, fmt.Errorf("failed to run lifecycle: %w", err)
+	}
+
+	env := map[string]string{
+		"CNB_PLATFORM_API":      l.platformAPI,
+		"CNB_EXPERIMENTAL_MODE": "warn",
+	}
+
+	container, err := l.docker.Container.Run.
+		WithEntrypoint("/bin/sh").
+		WithCommand("/occam/run.sh").
+		WithEnv(env).
+		WithVolumes(
+			fmt.Sprintf("%s:/workspace", filepath.Join(dir, "workspace")),
+			fmt.Sprintf("%s:/layers", result.LayersDir),
+			fmt.Sprintf("%s:/platform", filepath.Join(dir, "platform")),
+			fmt.Sprintf("%s:/layout", result.LayoutDir),
+			fmt.Sprintf("%s:/occam", filepath.Join(dir, "occam")),
+		).
+		Execute(builder)
+	if err != nil {
+		return result, fmt.Errorf("failed to run lifecycle: %w", err)
+	}
+	defer func() {
+		if err := l.docker.Container.Remove.Execute(container.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to remove lifecycle container: %s\n", err)
+		}
+	}()
+
+	exitCode, err := l.docker.Container.Wait.Execute(container.ID)
+	if err != nil {
+		return result, fmt.Errorf("failed to run lifecycle: %w", err)
+	}
+
+	logs, err := l.docker.Container.Logs.Execute(container.ID)
+	if err != nil {
+		return result, fmt.Errorf("failed to run lifecycle: %w", err)
+	}
+	result.Logs = logs.String()
+
+	for _, phase := range l.phases {
+		content, err := os.ReadFile(filepath.Join(dir, "occam", "exit-codes", string(phase)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				break
+			}
+
+			return result, fmt.Errorf("failed to read exit code of %s: %w", phase, err)
+		}
+
+		code, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return result, fmt.Errorf("failed to read exit code of %s: %w", phase, err)
+		}
+
+		result.ExitCodes[phase] = code
+	}
+
+	if len(result.ExitCodes) == 0 {
+		return result, fmt.Errorf("failed to run lifecycle: container exited with status %d: %s", exitCode, strings.TrimSpace(result.Logs))
+	}
+
+	var group struct {
+		Group []LifecycleBuildpack `toml:"group"`
+	}
+	err = decodeLifecycleFile(filepath.Join(result.LayersDir, "group.toml"), &group)
+	if err != nil {
+		return result, err
+	}
+	result.Group = group.Group
+
+	var plan struct {
+		Entries []LifecyclePlanEntry `toml:"entries"`
+	}
+	err = decodeLifecycleFile(filepath.Join(result.LayersDir, "plan.toml"), &plan)
+	if err != nil {
+		return result, err
+	}
+	result.Plan = plan.Entries
+
+	return result, nil
+}
+
+// prepare lays out the directories mounted into the lifecycle container and
+// writes the script that runs the phases.
+func (l Lifecycle) prepare(dir, source string) error {
+	for _, name := range []string{"layers", "platform/env", "layout", "occam/exit-codes"} {
+		err := os.MkdirAll(filepath.Join(dir, name), os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := fs.Copy(source, filepath.Join(dir, "workspace"))
+	if err != nil {
+		return fmt.Errorf("failed to copy source: %w", err)
+	}
+
+	if l.layers != "" {
+		entries, err := os.ReadDir(l.layers)
+		if err != nil {
+			return fmt.Errorf("failed to copy layers: %w", err)
+		}
+
+		for _, entry := range entries {
+			err = fs.Copy(filepath.Join(l.layers, entry.Name()), filepath.Join(dir, "layers", entry.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to copy layers: %w", err)
+			}
+		}
+	}
+
+	for key, value := range l.env {
+		err = os.WriteFile(filepath.Join(dir, "platform", "env", key), []byte(value), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.runImage != "" {
+		err = os.WriteFile(filepath.Join(dir, "occam", "run.toml"), []byte(fmt.Sprintf("[[images]]\n  image = %q\n", l.runImage)), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "occam", "run.sh"), []byte(l.script()), 0755)
+	if err != nil {
+		return err
+	}
+
+	// The phases run as the user of the builder image, which needs to write
+	// to the mounted directories.
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		if info.IsDir() {
+			return os.Chmod(path, 0777)
+		}
+
+		return os.Chmod(path, info.Mode().Perm()|0666)
+	})
+}
+
+// script returns a shell script that runs the phases in order, records the
+// exit code of each one under /occam/exit-codes and stops at the first
+// failure. The mounted directories are made writable again afterwards so
+// that they can be removed from the host.
+func (l Lifecycle) script() string {
+	lines := []string{
+		"#!/bin/sh",
+		"finish() {",
+		"  chmod -R a+rwX /workspace /layers /layout 2>/dev/null",
+		"  exit \"$1\"",
+		"}",
+	}
+
+	for _, phase := range l.phases {
+		args := []string{fmt.Sprintf("/cnb/lifecycle/%s", phase)}
+
+		switch phase {
+		case LifecycleAnalyzer:
+			args = append(args, "-layers", "/layers", "-analyzed", "/layers/analyzed.toml", "-layout", "-layout-dir", "/layout")
+
+			// Platform API 0.12 deprecates -run-image in favour of a run.toml.
+			if l.runImage != "" {
+				if platformAPIBefore(l.platformAPI, 12) {
+					args = append(args, "-run-image", l.runImage)
+				} else {
+					args = append(args, "-run", "/occam/run.toml")
+				}
+			}
+
+			args = append(args, "occam/app")
+		case LifecycleDetector, LifecycleBuilder:
+			args = append(args, "-app", "/workspace", "-layers", "/layers", "-group", "/layers/group.toml", "-platform", "/platform", "-plan", "/layers/plan.toml")
+		case LifecycleExporter:
+			args = append(args, "-app", "/workspace", "-layers", "/layers", "-group", "/layers/group.toml", "-analyzed", "/layers/analyzed.toml", "-layout", "-layout-dir", "/layout", "occam/app")
+		}
+
+		lines = append(lines,
+			strings.Join(args, " "),
+			"code=$?",
+			fmt.Sprintf("echo \"$code\" > /occam/exit-codes/%s", phase),
+			"[ \"$code\" -eq 0 ] || finish \"$code\"",
+		)
+	}
+
+	lines = append(lines, "finish 0", "")
+
+	return strings.Join(lines, "\n")
+}
+
+// platformAPIBefore reports whether a 0.x platform API is older than 0.<minor>.
+func platformAPIBefore(api string, minor int) bool {
+	major, apiMinor, _ := strings.Cut(api, ".")
+	version, err := strconv.Atoi(apiMinor)
+	if major != "0" || err != nil {
+		return false
+	}
+
+	return version < minor
+}
+
+func decodeLifecycleFile(path string, v interface{}) error {
+	_, err := toml.DecodeFile(path, v)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
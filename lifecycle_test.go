@@ -0,0 +1,360 @@
+package occam_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLifecycle(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+		executions []pexec.Execution
+		mounts     map[string]string
+		exitCodes  map[string]string
+		script     string
+
+		source string
+
+		lifecycle occam.Lifecycle
+	)
+
+	it.Before(func() {
+		source = t.TempDir()
+		Expect(os.WriteFile(filepath.Join(source, "main.go"), []byte("package main"), 0600)).To(Succeed())
+
+		executions = nil
+		mounts = map[string]string{}
+		exitCodes = map[string]string{"detector": "0", "builder": "0"}
+
+		executable = &fakes.Executable{}
+		executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			executions = append(executions, execution)
+
+			switch execution.Args[1] {
+			case "run":
+				for i, arg := range execution.Args {
+					if arg == "--volume" {
+						parts := strings.SplitN(execution.Args[i+1], ":", 2)
+						mounts[parts[1]] = parts[0]
+					}
+				}
+
+				content, err := os.ReadFile(filepath.Join(mounts["/occam"], "run.sh"))
+				if err != nil {
+					return err
+				}
+				script = string(content)
+
+				_, _ = fmt.Fprintln(execution.Stdout, "some-container-id")
+			case "inspect":
+				_, _ = fmt.Fprintln(execution.Stdout, `[{"Id": "some-container-id"}]`)
+			case "wait":
+				for phase, code := range exitCodes {
+					err := os.WriteFile(filepath.Join(mounts["/occam"], "exit-codes", phase), []byte(code+"\n"), 0600)
+					if err != nil {
+						return err
+					}
+				}
+
+				err := os.WriteFile(filepath.Join(mounts["/layers"], "group.toml"), []byte(`
+[[group]]
+  id = "some-org/some-buildpack"
+  version = "1.2.3"
+  api = "0.8"
+`), 0600)
+				if err != nil {
+					return err
+				}
+
+				err = os.WriteFile(filepath.Join(mounts["/layers"], "plan.toml"), []byte(`
+[[entries]]
+
+  [[entries.providers]]
+    id = "some-org/some-buildpack"
+    version = "1.2.3"
+
+  [[entries.requires]]
+    name = "some-dependency"
+    [entries.requires.metadata]
+      build = true
+`), 0600)
+				if err != nil {
+					return err
+				}
+
+				_, _ = fmt.Fprintln(execution.Stdout, "0")
+			case "logs":
+				_, _ = fmt.Fprintln(execution.Stdout, "some-lifecycle-output")
+			}
+
+			return nil
+		}
+
+		lifecycle = occam.NewLifecycle().
+			WithDocker(occam.NewDocker().WithExecutable(executable))
+	})
+
+	it("runs the detector and builder in a container of the builder image", func() {
+		result, err := lifecycle.
+			WithEnv(map[string]string{"BP_SOME_VAR": "some-value"}).
+			Execute("some-builder", source)
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(result.Dir)
+
+		Expect(result.ExitCodes).To(Equal(map[occam.LifecyclePhase]int{
+			occam.LifecycleDetector: 0,
+			occam.LifecycleBuilder:  0,
+		}))
+		Expect(result.Group).To(Equal([]occam.LifecycleBuildpack{
+			{ID: "some-org/some-buildpack", Version: "1.2.3", API: "0.8"},
+		}))
+		Expect(result.Plan).To(Equal([]occam.LifecyclePlanEntry{
+			{
+				Providers: []occam.LifecycleBuildpack{{ID: "some-org/some-buildpack", Version: "1.2.3"}},
+				Requires: []occam.LifecyclePlanRequirement{
+					{Name: "some-dependency", Metadata: map[string]interface{}{"build": true}},
+				},
+			},
+		}))
+		Expect(result.LayersDir).To(Equal(mounts["/layers"]))
+		Expect(result.LayoutDir).To(Equal(mounts["/layout"]))
+		Expect(result.Logs).To(Equal("some-lifecycle-output\n"))
+
+		Expect(executions[0].Args).To(Equal([]string{
+			"container", "run", "--detach",
+			"--env", "CNB_EXPERIMENTAL_MODE=warn",
+			"--env", "CNB_PLATFORM_API=0.12",
+			"--entrypoint", "/bin/sh",
+			"--volume", fmt.Sprintf("%s:/workspace", mounts["/workspace"]),
+			"--volume", fmt.Sprintf("%s:/layers", mounts["/layers"]),
+			"--volume", fmt.Sprintf("%s:/platform", mounts["/platform"]),
+			"--volume", fmt.Sprintf("%s:/layout", mounts["/layout"]),
+			"--volume", fmt.Sprintf("%s:/occam", mounts["/occam"]),
+			"some-builder",
+			"/occam/run.sh",
+		}))
+		Expect(executions[len(executions)-1].Args).To(Equal([]string{"container", "rm", "some-container-id", "--force"}))
+
+		Expect(filepath.Join(mounts["/workspace"], "main.go")).To(BeARegularFile())
+
+		content, err := os.ReadFile(filepath.Join(mounts["/platform"], "env", "BP_SOME_VAR"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("some-value"))
+
+		Expect(script).To(ContainSubstring("/cnb/lifecycle/detector -app /workspace -layers /layers -group /layers/group.toml -platform /platform -plan /layers/plan.toml\n"))
+		Expect(script).To(ContainSubstring("/cnb/lifecycle/builder -app /workspace -layers /layers -group /layers/group.toml -platform /platform -plan /layers/plan.toml\n"))
+		Expect(script).NotTo(ContainSubstring("exporter"))
+	})
+
+	context("when a phase fails", func() {
+		it.Before(func() {
+			exitCodes = map[string]string{"detector": "100"}
+		})
+
+		it("reports its exit code and omits the phases that did not run", func() {
+			result, err := lifecycle.Execute("some-builder", source)
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(result.Dir)
+
+			Expect(result.ExitCodes).To(Equal(map[occam.LifecyclePhase]int{
+				occam.LifecycleDetector: 100,
+			}))
+		})
+	})
+
+	context("when running a single phase with existing layers", func() {
+		var layers string
+
+		it.Before(func() {
+			layers = t.TempDir()
+			Expect(os.MkdirAll(filepath.Join(layers, "some-org_some-buildpack"), os.ModePerm)).To(Succeed())
+
+			exitCodes = map[string]string{"builder": "0"}
+		})
+
+		it("runs only that phase on a copy of the layers", func() {
+			result, err := lifecycle.
+				WithPhases(occam.LifecycleBuilder).
+				WithLayers(layers).
+				Execute("some-builder", source)
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(result.Dir)
+
+			Expect(result.ExitCodes).To(Equal(map[occam.LifecyclePhase]int{
+				occam.LifecycleBuilder: 0,
+			}))
+			Expect(filepath.Join(result.LayersDir, "some-org_some-buildpack")).To(BeADirectory())
+			Expect(script).NotTo(ContainSubstring("detector"))
+		})
+	})
+
+	context("when exporting", func() {
+		var calls string
+
+		it.Before(func() {
+			// The script is run on the host against fake lifecycle binaries
+			// that check what a real exporter needs: an analyzed.toml, written
+			// by the analyzer, that names the run image.
+			lifecycleDir := t.TempDir()
+			calls = filepath.Join(t.TempDir(), "calls")
+
+			binaries := map[string]string{
+				"analyzer": `
+analyzed=""; run=""; image=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -analyzed) analyzed="$2"; shift ;;
+    -run) run="$2"; shift ;;
+    -run-image) image="$2"; shift ;;
+  esac
+  shift
+done
+[ -z "$run" ] || image=$(sed -n 's/^ *image = "\(.*\)"/\1/p' "$run")
+printf '[run-image]\n  reference = "%s"\n' "$image" > "$analyzed"
+`,
+				"detector": "",
+				"builder":  "",
+				"exporter": `
+analyzed=""; layout=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -analyzed) analyzed="$2"; shift ;;
+    -layout-dir) layout="$2"; shift ;;
+  esac
+  shift
+done
+grep -q 'reference = "paketobuildpacks/run-jammy-base:latest"' "$analyzed" || { echo "no run image in analyzed.toml" >&2; exit 1; }
+touch "$layout/index.json"
+`,
+			}
+			for phase, body := range binaries {
+				content := fmt.Sprintf("#!/bin/sh\necho %s >> %s\n%s", phase, calls, body)
+				Expect(os.WriteFile(filepath.Join(lifecycleDir, phase), []byte(content), 0755)).To(Succeed())
+			}
+
+			stub := executable.ExecuteCall.Stub
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				if execution.Args[1] != "wait" {
+					return stub(execution)
+				}
+
+				executions = append(executions, execution)
+
+				host := strings.NewReplacer(
+					"/cnb/lifecycle", lifecycleDir,
+					"/workspace", mounts["/workspace"],
+					"/layers", mounts["/layers"],
+					"/platform", mounts["/platform"],
+					"/layout", mounts["/layout"],
+					"/occam", mounts["/occam"],
+				)
+
+				command := exec.Command("sh", "-c", host.Replace(script))
+				command.Stderr = os.Stderr
+				if err := command.Run(); err != nil {
+					return err
+				}
+
+				_, _ = fmt.Fprintln(execution.Stdout, "0")
+				return nil
+			}
+		})
+
+		it("records the run image with the analyzer and exports to an OCI layout", func() {
+			result, err := lifecycle.
+				WithPhases(occam.LifecycleDetector, occam.LifecycleBuilder, occam.LifecycleExporter).
+				WithRunImage("paketobuildpacks/run-jammy-base:latest").
+				Execute("some-builder", source)
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(result.Dir)
+
+			Expect(result.ExitCodes).To(Equal(map[occam.LifecyclePhase]int{
+				occam.LifecycleAnalyzer: 0,
+				occam.LifecycleDetector: 0,
+				occam.LifecycleBuilder:  0,
+				occam.LifecycleExporter: 0,
+			}))
+			Expect(filepath.Join(result.LayoutDir, "index.json")).To(BeARegularFile())
+
+			content, err := os.ReadFile(calls)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("analyzer\ndetector\nbuilder\nexporter\n"))
+
+			Expect(script).To(ContainSubstring("/cnb/lifecycle/analyzer -layers /layers -analyzed /layers/analyzed.toml -layout -layout-dir /layout -run /occam/run.toml occam/app\n"))
+			Expect(script).To(ContainSubstring("/cnb/lifecycle/exporter -app /workspace -layers /layers -group /layers/group.toml -analyzed /layers/analyzed.toml -layout -layout-dir /layout occam/app\n"))
+			Expect(script).NotTo(ContainSubstring("-run-image"))
+		})
+
+		context("with a platform API older than 0.12", func() {
+			it("gives the analyzer the run image as a flag", func() {
+				result, err := lifecycle.
+					WithPhases(occam.LifecycleExporter).
+					WithRunImage("paketobuildpacks/run-jammy-base:latest").
+					WithPlatformAPI("0.11").
+					Execute("some-builder", source)
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(result.Dir)
+
+				Expect(result.ExitCodes).To(HaveKeyWithValue(occam.LifecycleExporter, 0))
+				Expect(script).To(ContainSubstring("/cnb/lifecycle/analyzer -layers /layers -analyzed /layers/analyzed.toml -layout -layout-dir /layout -run-image paketobuildpacks/run-jammy-base:latest occam/app\n"))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the exporter is run without a run image", func() {
+			it("returns an error", func() {
+				_, err := lifecycle.WithPhases(occam.LifecycleExporter).Execute("some-builder", source)
+				Expect(err).To(MatchError("failed to run lifecycle: the exporter requires a run image"))
+			})
+		})
+
+		context("when given an unknown phase", func() {
+			it("returns an error", func() {
+				_, err := lifecycle.WithPhases("restorer").Execute("some-builder", source)
+				Expect(err).To(MatchError(`failed to run lifecycle: unknown phase "restorer"`))
+			})
+		})
+
+		context("when the container cannot be run", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stderr, "Unable to find image 'some-builder:latest' locally")
+					return errors.New("exit status 125")
+				}
+			})
+
+			it("returns an error", func() {
+				result, err := lifecycle.Execute("some-builder", source)
+				defer os.RemoveAll(result.Dir)
+				Expect(err).To(MatchError("failed to run lifecycle: failed to run docker container: exit status 125: Unable to find image 'some-builder:latest' locally"))
+			})
+		})
+
+		context("when no phase runs", func() {
+			it.Before(func() {
+				exitCodes = map[string]string{}
+			})
+
+			it("returns an error with the container logs", func() {
+				result, err := lifecycle.Execute("some-builder", source)
+				defer os.RemoveAll(result.Dir)
+				Expect(err).To(MatchError("failed to run lifecycle: container exited with status 0: some-lifecycle-output"))
+			})
+		})
+	})
+}
@@ -2,13 +2,29 @@ package occam
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
-
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 )
 
@@ -22,35 +38,97 @@ type DockerImageInspectClient interface {
 	Execute(ref string) (Image, error)
 }
 
+//go:generate faux --interface DockerImageRemoveClient --output fakes/docker_image_remove_client.go
+type DockerImageRemoveClient interface {
+	Execute(ref string) error
+}
+
+// CleanupRegistrar registers functions to run when a test finishes. It is
+// satisfied by *testing.T and *testing.B.
+type CleanupRegistrar interface {
+	Cleanup(func())
+}
+
 type Pack struct {
-	Build   PackBuild
-	Builder PackBuilder
+	Build     PackBuild
+	Builder   PackBuilder
+	Buildpack PackBuildpack
+
+	executable Executable
+	ctx        context.Context
+	version    *packVersionCache
 }
 
 func NewPack() Pack {
-	executable := pexec.NewExecutable("pack")
+	executable := command.New("pack")
+	version := &packVersionCache{}
 
 	return Pack{
+		executable: executable,
+		version:    version,
 		Build: PackBuild{
 			executable:               executable,
+			version:                  version,
 			dockerImageInspectClient: NewDocker().Image.Inspect,
 		},
 		Builder: PackBuilder{
 			Inspect: PackBuilderInspect{
 				executable: executable,
 			},
+			Create: PackBuilderCreate{
+				executable:              executable,
+				inspect:                 PackBuilderInspect{executable: executable},
+				dockerImageRemoveClient: NewDocker().Image.Remove,
+				tempOutput:              os.MkdirTemp,
+			},
+		},
+		Buildpack: PackBuildpack{
+			Inspect: PackBuildpackInspect{
+				dockerImageInspectClient: NewDocker().Image.Inspect,
+			},
 		},
 	}
 }
 
 func (p Pack) WithExecutable(executable Executable) Pack {
+	p.executable = executable
+	p.version = &packVersionCache{}
+	return p.withExecutable()
+}
+
+// WithContext stops the pack commands when the context is done. Pack is
+// killed, and the command fails with an error saying it timed out, followed
+// by the output pack wrote until then.
+func (p Pack) WithContext(ctx context.Context) Pack {
+	p.ctx = ctx
+	return p.withExecutable()
+}
+
+func (p Pack) withExecutable() Pack {
+	executable := command.WithContext(p.ctx, "pack", p.executable)
+
 	p.Build.executable = executable
+	p.Build.version = p.version
 	p.Builder.Inspect.executable = executable
+	p.Builder.Create.executable = executable
+	p.Builder.Create.inspect.executable = executable
 	return p
 }
 
+// Version returns the version of the installed pack, which can be checked
+// for a capability with PackVersion.Supports. It is only detected once.
+func (p Pack) Version() (PackVersion, error) {
+	return p.version.get(command.WithContext(p.ctx, "pack", p.executable))
+}
+
 func (p Pack) WithDockerImageInspectClient(client DockerImageInspectClient) Pack {
 	p.Build.dockerImageInspectClient = client
+	p.Buildpack.Inspect.dockerImageInspectClient = client
+	return p
+}
+
+func (p Pack) WithDockerImageRemoveClient(client DockerImageRemoveClient) Pack {
+	p.Builder.Create.dockerImageRemoveClient = client
 	return p
 }
 
@@ -66,6 +144,7 @@ func (p Pack) WithNoColor() Pack {
 
 type PackBuild struct {
 	executable               Executable
+	version                  *packVersionCache
 	dockerImageInspectClient DockerImageInspectClient
 
 	verbose bool
@@ -82,10 +161,37 @@ type PackBuild struct {
 	sbomOutputDir       string
 	volumes             []string
 	caches              []string
+	cacheFallback       bool
 	gid                 string
 	runImage            string
 	additionalBuildArgs []string
 
+	publish            bool
+	registry           string
+	registryUsername   string
+	registryPassword   string
+	dockerConfig       string
+	insecureRegistries []string
+
+	bindings []Binding
+
+	envFiles          []string
+	descriptor        string
+	projectDescriptor *ProjectDescriptor
+
+	lifecycleImage string
+	creationTime   *time.Time
+	previousImage  string
+	tags           []string
+	cacheImage     string
+	defaultProcess string
+	workspace      string
+	uid            string
+	usernsHost     bool
+
+	logWriter io.Writer
+	logPrefix string
+
 	// TODO: remove after deprecation period
 	noPull bool
 }
@@ -171,13 +277,523 @@ func (pb PackBuild) WithVolumes(volumes ...string) PackBuild {
 	return pb
 }
 
+// WithBindings gives the service bindings to the buildpacks. They are written
+// to a temporary directory, mounted at /platform/bindings with
+// SERVICE_BINDING_ROOT pointing at it, and removed after the build.
+func (pb PackBuild) WithBindings(bindings ...Binding) PackBuild {
+	pb.bindings = append(slices.Clone(pb.bindings), bindings...)
+	return pb
+}
+
+// WithEnvFiles passes the given files of build-time environment variables to
+// pack with `--env-file`.
+func (pb PackBuild) WithEnvFiles(files ...string) PackBuild {
+	pb.envFiles = append(slices.Clone(pb.envFiles), files...)
+	return pb
+}
+
+// WithDescriptor passes the project.toml at the given path to pack with
+// `--descriptor`.
+func (pb PackBuild) WithDescriptor(path string) PackBuild {
+	pb.descriptor = path
+	return pb
+}
+
+// WithProjectDescriptor builds the app with the given project descriptor. It
+// is written to a temporary project.toml passed to pack with `--descriptor`,
+// so the app source is left untouched. Pack would resolve relative buildpack
+// URIs against that temporary directory, so they are rejected; use
+// WriteProjectDescriptor to write a descriptor with relative URIs into the app.
+func (pb PackBuild) WithProjectDescriptor(descriptor ProjectDescriptor) PackBuild {
+	pb.projectDescriptor = &descriptor
+	return pb
+}
+
+// WithLifecycleImage sets the image the lifecycle is run from, with
+// `--lifecycle-image`.
+func (pb PackBuild) WithLifecycleImage(image string) PackBuild {
+	pb.lifecycleImage = image
+	return pb
+}
+
+// WithCreationTime sets the creation time of the app image, with
+// `--creation-time`.
+func (pb PackBuild) WithCreationTime(creationTime time.Time) PackBuild {
+	pb.creationTime = &creationTime
+	return pb
+}
+
+// WithPreviousImage sets the image whose layers are reused, with
+// `--previous-image`.
+func (pb PackBuild) WithPreviousImage(image string) PackBuild {
+	pb.previousImage = image
+	return pb
+}
+
+// WithTags sets additional tags for the app image, with `--tag`.
+func (pb PackBuild) WithTags(tags ...string) PackBuild {
+	pb.tags = append(slices.Clone(pb.tags), tags...)
+	return pb
+}
+
+// WithCacheImage caches the build layers in the given image, with
+// `--cache-image`. It requires publishing.
+func (pb PackBuild) WithCacheImage(image string) PackBuild {
+	pb.cacheImage = image
+	return pb
+}
+
+// WithDefaultProcess sets the default process type of the app image, with
+// `--default-process`.
+func (pb PackBuild) WithDefaultProcess(process string) PackBuild {
+	pb.defaultProcess = process
+	return pb
+}
+
+// WithWorkspace sets the location of the app in the image, with
+// `--workspace`.
+func (pb PackBuild) WithWorkspace(workspace string) PackBuild {
+	pb.workspace = workspace
+	return pb
+}
+
+// WithUID overrides the UID of the user in the app image, with `--uid`.
+func (pb PackBuild) WithUID(uid string) PackBuild {
+	pb.uid = uid
+	return pb
+}
+
+// WithUsernsHost runs the build containers in the user namespace of the host,
+// with `--userns host`.
+func (pb PackBuild) WithUsernsHost() PackBuild {
+	pb.usernsHost = true
+	return pb
+}
+
+// WithLogWriter streams the output of pack to the writer while the build
+// runs. The output is still returned from Execute once the build is done.
+func (pb PackBuild) WithLogWriter(writer io.Writer) PackBuild {
+	pb.logWriter = writer
+	return pb
+}
+
+// WithLogPrefix sets the prefix of every line streamed to the writer given
+// with WithLogWriter, such as the name of the app, to tell builds apart.
+func (pb PackBuild) WithLogPrefix(prefix string) PackBuild {
+	pb.logPrefix = prefix
+	return pb
+}
+
 func (pb PackBuild) WithCaches(caches ...string) PackBuild {
 	pb.caches = append(pb.caches, caches...)
 	return pb
 }
 
+// WithCache gives the cache to pack with `--cache`.
+func (pb PackBuild) WithCache(cache Cache) PackBuild {
+	pb.caches = append(slices.Clone(pb.caches), cache.String())
+	return pb
+}
+
+// WithCacheFallback leaves out the default caches when the installed pack is
+// too old for `--cache`, rather than failing, as that pack gives its cache
+// volumes the same names itself. The pack version is only detected for it
+// when the build uses the default caches.
+func (pb PackBuild) WithCacheFallback() PackBuild {
+	pb.cacheFallback = true
+	return pb
+}
+
+// Caches returns the caches used by a build of the image with the given name:
+// those given with WithCaches, WithCache or as additional `--cache` build
+// args, or else the DefaultCaches. They can be removed with
+// Docker.Cache.Remove once the test is done.
+func (pb PackBuild) Caches(name string) ([]Cache, error) {
+	values := slices.Clone(pb.caches)
+	for i, arg := range pb.additionalBuildArgs {
+		switch {
+		case arg == "--cache" && i+1 < len(pb.additionalBuildArgs):
+			values = append(values, pb.additionalBuildArgs[i+1])
+		case strings.HasPrefix(arg, "--cache="):
+			values = append(values, strings.TrimPrefix(arg, "--cache="))
+		}
+	}
+
+	if len(values) == 0 {
+		return DefaultCaches(name), nil
+	}
+
+	var caches []Cache
+	for _, value := range values {
+		cache, err := ParseCache(value)
+		if err != nil {
+			return nil, err
+		}
+
+		caches = append(caches, cache)
+	}
+
+	return caches, nil
+}
+
+// WithPublish publishes the image to the given registry with `--publish`,
+// instead of exporting it to the Docker daemon. The name given to Execute is
+// then a repository in that registry, and the returned Image is inspected
+// through the registry. An empty registry means the name is a full
+// reference.
+//
+// The lifecycle pushes the image from inside a container, so a registry on
+// localhost, such as a LocalRegistry, requires WithNetwork("host").
+func (pb PackBuild) WithPublish(registry string) PackBuild {
+	pb.publish = true
+	pb.registry = registry
+	return pb
+}
+
+// WithRegistryAuth sets the credentials used to publish the image and to
+// inspect it afterwards. They are added to a copy of the Docker config, which
+// keeps the credentials for other registries.
+func (pb PackBuild) WithRegistryAuth(username, password string) PackBuild {
+	pb.registryUsername = username
+	pb.registryPassword = password
+	return pb
+}
+
+// WithDockerConfig sets the directory holding the Docker config.json the
+// registry credentials are read from. It defaults to DOCKER_CONFIG or
+// ~/.docker.
+func (pb PackBuild) WithDockerConfig(dir string) PackBuild {
+	pb.dockerConfig = dir
+	return pb
+}
+
+// WithInsecureRegistries allows the given registries to be reached over
+// plain HTTP when publishing.
+func (pb PackBuild) WithInsecureRegistries(registries ...string) PackBuild {
+	pb.insecureRegistries = append(pb.insecureRegistries, registries...)
+	return pb
+}
+
 func (pb PackBuild) Execute(name, path string) (Image, fmt.Stringer, error) {
-	args := []string{"build", name}
+	if pb.publish {
+		return pb.executePublish(name, path)
+	}
+
+	buildLogBuffer, err := pb.run(name, name, path)
+	if err != nil {
+		return Image{}, buildLogBuffer, err
+	}
+
+	image, err := pb.dockerImageInspectClient.Execute(name)
+	if err != nil {
+		return Image{}, buildLogBuffer, fmt.Errorf("failed to pack build: %w", err)
+	}
+
+	return image, buildLogBuffer, nil
+}
+
+// ExecuteToOCILayout builds the app into an OCI layout at layoutDir, using
+// pack's `oci:` target, instead of exporting it to the Docker daemon. The
+// returned image can be given to the file matchers, and described with
+// NewImageFromV1Image. The name is used to key the build caches.
+//
+// Exporting to an OCI layout is an experimental pack feature, enabled with
+// `pack config experimental true`.
+func (pb PackBuild) ExecuteToOCILayout(name, path, layoutDir string) (v1.Image, fmt.Stringer, error) {
+	buildLogBuffer, err := pb.run(name, fmt.Sprintf("oci:%s", layoutDir), path)
+	if err != nil {
+		return nil, buildLogBuffer, err
+	}
+
+	image, err := readOCILayoutImage(layoutDir)
+	if err != nil {
+		return nil, buildLogBuffer, fmt.Errorf("failed to pack build: %w", err)
+	}
+
+	return image, buildLogBuffer, nil
+}
+
+func (pb PackBuild) executePublish(imageName, path string) (Image, fmt.Stringer, error) {
+	ref := imageName
+	if pb.registry != "" {
+		ref = fmt.Sprintf("%s/%s", strings.TrimSuffix(pb.registry, "/"), imageName)
+	}
+
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return Image{}, bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+	}
+
+	if isLoopbackRegistry(reference.Context().RegistryStr()) && pb.network != "host" {
+		return Image{}, bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: cannot publish to %s: the registry is only reachable from containers on the host network, use WithNetwork(\"host\")", reference.Context().RegistryStr())
+	}
+
+	if slices.Contains(pb.insecureRegistries, reference.Context().RegistryStr()) {
+		reference, err = name.ParseReference(ref, name.Insecure)
+		if err != nil {
+			return Image{}, bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+		}
+	}
+
+	dockerConfig := pb.dockerConfig
+	if pb.registryUsername != "" {
+		base := dockerConfig
+		if base == "" {
+			base = os.Getenv(config.EnvOverrideConfigDir)
+		}
+
+		if base == "" {
+			base = config.Dir()
+		}
+
+		dockerConfig, err = writeDockerConfig(base, reference.Context().RegistryStr(), pb.registryUsername, pb.registryPassword)
+		if err != nil {
+			return Image{}, bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(dockerConfig); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to clean up docker config: %s\n", err)
+			}
+		}()
+	}
+
+	var env []string
+	if dockerConfig != "" {
+		env = append(env, fmt.Sprintf("DOCKER_CONFIG=%s", dockerConfig))
+	}
+
+	buildLogBuffer, err := pb.run(imageName, ref, path, env...)
+	if err != nil {
+		return Image{}, buildLogBuffer, err
+	}
+
+	var keychain authn.Keychain = authn.DefaultKeychain
+	if dockerConfig != "" {
+		keychain = dockerConfigKeychain{dir: dockerConfig}
+	}
+
+	remoteImage, err := remote.Image(reference, remote.WithAuthFromKeychain(keychain))
+	if err != nil {
+		return Image{}, buildLogBuffer, fmt.Errorf("failed to pack build: failed to inspect published image: %w", err)
+	}
+
+	image, err := NewImageFromV1Image(remoteImage)
+	if err != nil {
+		return Image{}, buildLogBuffer, fmt.Errorf("failed to pack build: %w", err)
+	}
+
+	return image, buildLogBuffer, nil
+}
+
+// isLoopbackRegistry reports whether the registry is served on localhost.
+func isLoopbackRegistry(registry string) bool {
+	host, _, err := net.SplitHostPort(registry)
+	if err != nil {
+		host = registry
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// writeDockerConfig writes a copy of the Docker config.json in the base
+// directory, with the credentials for the registry added, to a temporary
+// directory, and returns that directory. The other credentials of the config
+// are kept. A credsStore would shadow the added credentials, so it is kept as a
+// credHelpers entry for each of the other registries in the config instead.
+func writeDockerConfig(base, registry, username, password string) (string, error) {
+	cfg := map[string]json.RawMessage{}
+	content, err := os.ReadFile(filepath.Join(base, "config.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read docker config: %w", err)
+	}
+
+	if err == nil {
+		err = json.Unmarshal(content, &cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse docker config: %w", err)
+		}
+	}
+
+	var (
+		auths   map[string]json.RawMessage
+		helpers map[string]string
+		store   string
+	)
+	for key, value := range map[string]interface{}{"auths": &auths, "credHelpers": &helpers, "credsStore": &store} {
+		if _, ok := cfg[key]; !ok {
+			continue
+		}
+
+		err = json.Unmarshal(cfg[key], value)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s of docker config: %w", key, err)
+		}
+	}
+
+	if auths == nil {
+		auths = map[string]json.RawMessage{}
+	}
+
+	if helpers == nil {
+		helpers = map[string]string{}
+	}
+
+	if store != "" {
+		for server := range auths {
+			if _, ok := helpers[server]; !ok {
+				helpers[server] = store
+			}
+		}
+
+		delete(cfg, "credsStore")
+	}
+	delete(helpers, registry)
+
+	auth, err := json.Marshal(map[string]string{
+		"auth": base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password))),
+	})
+	if err != nil {
+		return "", err
+	}
+	auths[registry] = auth
+
+	for key, value := range map[string]interface{}{"auths": auths, "credHelpers": helpers} {
+		cfg[key], err = json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(helpers) == 0 {
+		delete(cfg, "credHelpers")
+	}
+
+	content, err = json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "docker-config")
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "config.json"), content, 0600)
+	if err != nil {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up docker config: %s\n", err)
+		}
+
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// isRelativeURI reports whether the buildpack URI is a path relative to the
+// project descriptor, rather than an absolute path or a URI with a scheme.
+func isRelativeURI(uri string) bool {
+	if uri == "" || filepath.IsAbs(uri) {
+		return false
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme == "" {
+		return true
+	}
+
+	return parsed.Scheme == "file" && parsed.Host == "" && !strings.HasPrefix(parsed.Path, "/")
+}
+
+// dockerConfigKeychain resolves registry credentials from the Docker config in
+// the given directory.
+type dockerConfigKeychain struct {
+	dir string
+}
+
+func (k dockerConfigKeychain) Resolve(resource authn.Resource) (authn.Authenticator, error) {
+	cf, err := config.Load(k.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	key := resource.RegistryStr()
+	if key == name.DefaultRegistry {
+		key = "https://index.docker.io/v1/"
+	}
+
+	cfg, err := cf.GetAuthConfig(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Username == "" && cfg.Password == "" && cfg.Auth == "" && cfg.IdentityToken == "" && cfg.RegistryToken == "" {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		Auth:          cfg.Auth,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}), nil
+}
+
+// run executes `pack build` for the given image reference. The name keys the
+// caches and volumes of the build.
+func (pb PackBuild) run(name, ref, path string, extraEnv ...string) (*bytes.Buffer, error) {
+	if len(pb.bindings) > 0 {
+		dir, err := materializeBindings(pb.bindings)
+		if err != nil {
+			return bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to clean up bindings: %s\n", err)
+			}
+		}()
+
+		env := map[string]string{}
+		for key, value := range pb.env {
+			env[key] = value
+		}
+		env["SERVICE_BINDING_ROOT"] = buildBindingRoot
+
+		pb.env = env
+		pb.volumes = append(slices.Clone(pb.volumes), fmt.Sprintf("%s:%s:ro", dir, buildBindingRoot))
+	}
+
+	if pb.projectDescriptor != nil {
+		for _, buildpack := range pb.projectDescriptor.Buildpacks {
+			if isRelativeURI(buildpack.URI) {
+				return bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: project descriptor buildpack URI %q is relative: use an absolute path, or WriteProjectDescriptor to write the descriptor into the app", buildpack.URI)
+			}
+		}
+
+		dir, err := os.MkdirTemp("", "project-descriptor")
+		if err != nil {
+			return bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to clean up project descriptor: %s\n", err)
+			}
+		}()
+
+		pb.descriptor = filepath.Join(dir, "project.toml")
+		err = writeProjectDescriptor(pb.descriptor, *pb.projectDescriptor)
+		if err != nil {
+			return bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+		}
+	}
+
+	args := []string{"build", ref}
 
 	if pb.verbose {
 		args = append(args, "--verbose")
@@ -193,6 +809,11 @@ func (pb PackBuild) Execute(name, path string) (Image, fmt.Stringer, error) {
 		args = append(args, "--buildpack", buildpack)
 	}
 
+	var required []PackCapability
+	if len(pb.extensions) > 0 {
+		required = append(required, PackCapabilityExtension)
+	}
+
 	for _, extension := range pb.extensions {
 		args = append(args, "--extension", extension)
 	}
@@ -222,6 +843,14 @@ func (pb PackBuild) Execute(name, path string) (Image, fmt.Stringer, error) {
 		}
 	}
 
+	for _, file := range pb.envFiles {
+		args = append(args, "--env-file", file)
+	}
+
+	if pb.descriptor != "" {
+		args = append(args, "--descriptor", pb.descriptor)
+	}
+
 	if pb.noPull {
 		args = append(args, "--no-pull")
 	}
@@ -250,27 +879,79 @@ func (pb PackBuild) Execute(name, path string) (Image, fmt.Stringer, error) {
 		args = append(args, "--run-image", pb.runImage)
 	}
 
+	for _, option := range []struct {
+		capability PackCapability
+		values     []string
+	}{
+		{PackCapabilityLifecycleImage, nonEmpty(pb.lifecycleImage)},
+		{PackCapabilityCreationTime, creationTimeArgs(pb.creationTime)},
+		{PackCapabilityPreviousImage, nonEmpty(pb.previousImage)},
+		{PackCapabilityTag, pb.tags},
+		{PackCapabilityCacheImage, nonEmpty(pb.cacheImage)},
+		{PackCapabilityDefaultProcess, nonEmpty(pb.defaultProcess)},
+		{PackCapabilityWorkspace, nonEmpty(pb.workspace)},
+		{PackCapabilityUID, nonEmpty(pb.uid)},
+		{PackCapabilityUserns, usernsArgs(pb.usernsHost)},
+	} {
+		if len(option.values) > 0 {
+			required = append(required, option.capability)
+		}
+
+		for _, value := range option.values {
+			args = append(args, option.capability.flag(), value)
+		}
+	}
+
+	if pb.publish {
+		args = append(args, "--publish")
+	}
+
+	if len(pb.insecureRegistries) > 0 {
+		required = append(required, PackCapabilityInsecureRegistry)
+	}
+
+	for _, registry := range pb.insecureRegistries {
+		args = append(args, "--insecure-registry", registry)
+	}
+
 	cacheArgExists := false
 	for _, arg := range pb.additionalBuildArgs {
-		if arg == "--cache" {
+		if arg == "--cache" || strings.HasPrefix(arg, "--cache=") {
 			cacheArgExists = true
 			break
 		}
 	}
 
-	if len(pb.caches) == 0 && !cacheArgExists {
+	if len(pb.caches) > 0 {
+		required = append(required, PackCapabilityCache)
+	}
 
-		refName := []byte(fmt.Sprintf("%s:latest", name))
-		sum := sha256.Sum256(refName)
+	// With WithCacheFallback, the default caches are left out for a pack too
+	// old for --cache, which gives the cache volumes the same names itself.
+	// They are kept when the version cannot be found, unless an option
+	// requires it.
+	defaultCaches := len(pb.caches) == 0 && !cacheArgExists
+	if len(required) > 0 || (defaultCaches && pb.cacheFallback) {
+		version, err := pb.version.get(pb.executable)
+		if len(required) > 0 {
+			if err != nil {
+				return bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+			}
+
+			err = version.require(required...)
+			if err != nil {
+				return bytes.NewBuffer(nil), fmt.Errorf("failed to pack build: %w", err)
+			}
+		}
 
-		parts := strings.SplitN(name, "/", 2)
-		splitName := name
-		if len(parts) == 2 {
-			splitName = parts[1]
+		if err == nil && !version.Supports(PackCapabilityCache) {
+			defaultCaches = false
 		}
+	}
 
-		for _, t := range []string{"build", "launch"} {
-			pb.caches = append(pb.caches, fmt.Sprintf("type=%s;format=volume;name=pack-cache-%s_latest-%x.%s", t, splitName, sum[:6], t))
+	if defaultCaches {
+		for _, cache := range DefaultCaches(name) {
+			pb.caches = append(pb.caches, cache.String())
 		}
 	}
 
@@ -280,30 +961,80 @@ func (pb PackBuild) Execute(name, path string) (Image, fmt.Stringer, error) {
 
 	packEnv := os.Environ()
 	packEnv = append(packEnv, fmt.Sprintf("PACK_VOLUME_KEY=%s-volume", name))
+	packEnv = append(packEnv, extraEnv...)
 
 	args = append(args, pb.additionalBuildArgs...)
 
 	buildLogBuffer := bytes.NewBuffer(nil)
+	var output io.Writer = buildLogBuffer
+	if pb.logWriter != nil {
+		output = io.MultiWriter(buildLogBuffer, newPrefixWriter(pb.logWriter, pb.logPrefix))
+	}
+
 	err := pb.executable.Execute(pexec.Execution{
 		Args:   args,
-		Stdout: buildLogBuffer,
-		Stderr: buildLogBuffer,
+		Stdout: output,
+		Stderr: output,
 		Env:    packEnv,
 	})
 	if err != nil {
-		return Image{}, buildLogBuffer, fmt.Errorf("failed to pack build: %w\n\nOutput:\n%s", err, buildLogBuffer)
+		return buildLogBuffer, fmt.Errorf("failed to pack build: %w\n\nOutput:\n%s", err, buildLogBuffer)
 	}
 
-	image, err := pb.dockerImageInspectClient.Execute(name)
+	return buildLogBuffer, nil
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return []string{value}
+}
+
+func creationTimeArgs(creationTime *time.Time) []string {
+	if creationTime == nil {
+		return nil
+	}
+
+	return []string{strconv.FormatInt(creationTime.Unix(), 10)}
+}
+
+func usernsArgs(host bool) []string {
+	if !host {
+		return nil
+	}
+
+	return []string{"host"}
+}
+
+// readOCILayoutImage returns the image of the OCI layout at the given path.
+func readOCILayoutImage(path string) (v1.Image, error) {
+	layoutPath, err := layout.FromPath(path)
 	if err != nil {
-		return Image{}, buildLogBuffer, fmt.Errorf("failed to pack build: %w", err)
+		return nil, fmt.Errorf("failed to read OCI layout: %w", err)
 	}
 
-	return image, buildLogBuffer, nil
+	index, err := layoutPath.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout: %w", err)
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout: %w", err)
+	}
+
+	if len(manifest.Manifests) == 0 {
+		return nil, fmt.Errorf("failed to read OCI layout: %s holds no images", path)
+	}
+
+	return index.Image(manifest.Manifests[0].Digest)
 }
 
 type PackBuilder struct {
 	Inspect PackBuilderInspect
+	Create  PackBuilderCreate
 }
 
 type PackBuilderInspect struct {
@@ -332,3 +1063,160 @@ func (pbi PackBuilderInspect) Execute(names ...string) (Builder, error) {
 
 	return builder, nil
 }
+
+type PackBuilderCreate struct {
+	executable              Executable
+	inspect                 PackBuilderInspect
+	dockerImageRemoveClient DockerImageRemoveClient
+	tempOutput              func(dir string, pattern string) (string, error)
+
+	store      *BuildpackStore
+	cleanup    CleanupRegistrar
+	pullPolicy string
+}
+
+// WithBuildpackStore sets the store used to resolve the Ref of buildpacks and
+// extensions in the BuilderConfig.
+func (pbc PackBuilderCreate) WithBuildpackStore(store BuildpackStore) PackBuilderCreate {
+	pbc.store = &store
+	return pbc
+}
+
+// WithCleanup registers the removal of the created builder image with the
+// given registrar, usually the *testing.T of the test creating the builder.
+func (pbc PackBuilderCreate) WithCleanup(registrar CleanupRegistrar) PackBuilderCreate {
+	pbc.cleanup = registrar
+	return pbc
+}
+
+func (pbc PackBuilderCreate) WithPullPolicy(pullPolicy string) PackBuilderCreate {
+	pbc.pullPolicy = pullPolicy
+	return pbc
+}
+
+func (pbc PackBuilderCreate) WithTempOutput(tempOutput func(string, string) (string, error)) PackBuilderCreate {
+	pbc.tempOutput = tempOutput
+	return pbc
+}
+
+func (pbc PackBuilderCreate) Execute(name string, config BuilderConfig) (Builder, error) {
+	var err error
+	config.Buildpacks, err = pbc.resolve(config.Buildpacks)
+	if err != nil {
+		return Builder{}, err
+	}
+
+	config.Extensions, err = pbc.resolve(config.Extensions)
+	if err != nil {
+		return Builder{}, err
+	}
+
+	configDir, err := pbc.tempOutput("", "builder")
+	if err != nil {
+		return Builder{}, err
+	}
+	defer func() {
+		if err := os.RemoveAll(configDir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up builder config: %s\n", err)
+		}
+	}()
+
+	configPath := filepath.Join(configDir, "builder.toml")
+	file, err := os.Create(configPath)
+	if err != nil {
+		return Builder{}, fmt.Errorf("failed to create builder.toml: %w", err)
+	}
+
+	err = EncodeBuilderConfig(file, config)
+	if err != nil {
+		_ = file.Close()
+		return Builder{}, err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return Builder{}, fmt.Errorf("failed to close builder.toml: %w", err)
+	}
+
+	args := []string{"builder", "create", name, "--config", configPath}
+
+	if pbc.pullPolicy != "" {
+		args = append(args, "--pull-policy", pbc.pullPolicy)
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = pbc.executable.Execute(pexec.Execution{
+		Args:   args,
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return Builder{}, fmt.Errorf("failed to pack builder create: %w\n\nOutput:\n%s", err, buffer)
+	}
+
+	if pbc.cleanup != nil {
+		pbc.cleanup.Cleanup(func() {
+			if err := pbc.dockerImageRemoveClient.Execute(name); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to remove builder %s: %s\n", name, err)
+			}
+		})
+	}
+
+	builder, err := pbc.inspect.Execute(name)
+	if err != nil {
+		return Builder{}, fmt.Errorf("failed to pack builder create: %w", err)
+	}
+
+	return builder, nil
+}
+
+func (pbc PackBuilderCreate) resolve(buildpacks []BuilderConfigBuildpack) ([]BuilderConfigBuildpack, error) {
+	var resolved []BuilderConfigBuildpack
+	for _, buildpack := range buildpacks {
+		if buildpack.URI == "" && buildpack.Ref != "" {
+			if pbc.store == nil {
+				return nil, fmt.Errorf("failed to resolve %q: no buildpack store configured", buildpack.Ref)
+			}
+
+			path, err := pbc.store.Get.Execute(buildpack.Ref)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %q: %w", buildpack.Ref, err)
+			}
+
+			buildpack.URI = path
+		}
+
+		resolved = append(resolved, buildpack)
+	}
+
+	return resolved, nil
+}
+
+type PackBuildpack struct {
+	Inspect PackBuildpackInspect
+}
+
+type PackBuildpackInspect struct {
+	dockerImageInspectClient DockerImageInspectClient
+}
+
+// Execute inspects a packaged buildpack. The ref may be the path to a .cnb
+// file or buildpack directory, or the name of a buildpack image available to
+// the docker daemon, optionally prefixed with docker://.
+func (pbi PackBuildpackInspect) Execute(ref string) (BuildpackPackage, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return ReadBuildpackPackage(ref)
+	}
+
+	image, err := pbi.dockerImageInspectClient.Execute(strings.TrimPrefix(ref, "docker://"))
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to inspect buildpack %s: %w", ref, err)
+	}
+
+	pkg, err := NewBuildpackPackageFromLabels(image.Labels)
+	if err != nil {
+		return BuildpackPackage{}, fmt.Errorf("failed to inspect buildpack %s: %w", ref, err)
+	}
+
+	return pkg, nil
+}
@@ -1,10 +1,20 @@
 package occam_test
 
 import (
+	"bytes"
+	ctx "context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/paketo-buildpacks/occam"
 	"github.com/paketo-buildpacks/occam/fakes"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
@@ -64,6 +74,82 @@ func testPack(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("WithContext", func() {
+		var cancel ctx.CancelFunc
+
+		it.Before(func() {
+			var c ctx.Context
+			c, cancel = ctx.WithTimeout(ctx.Background(), 10*time.Millisecond)
+			pack = pack.WithContext(c)
+
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				if execution.Args[0] == "version" {
+					_, _ = fmt.Fprintln(execution.Stdout, "0.33.2")
+					return nil
+				}
+
+				_, _ = fmt.Fprintln(execution.Stdout, "some partial output")
+				<-c.Done()
+				return errors.New("signal: killed")
+			}
+		})
+
+		it.After(func() {
+			cancel()
+		})
+
+		it("returns a timeout error and the build logs so far", func() {
+			_, logs, err := pack.Build.Execute("myapp", "/some/app/path")
+			Expect(err).To(MatchError(ContainSubstring("failed to pack build: command `pack build myapp --path /some/app/path --cache")))
+			Expect(err).To(MatchError(ContainSubstring("timed out: context deadline exceeded\n\nOutput:\nsome partial output\n")))
+			Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+			Expect(logs.String()).To(Equal("some partial output\n"))
+		})
+
+		context("when the executable is set after the context", func() {
+			it("still stops it", func() {
+				other := &fakes.Executable{}
+				other.ExecuteCall.Stub = executable.ExecuteCall.Stub
+
+				_, _, err := pack.WithExecutable(other).Build.Execute("myapp", "/some/app/path")
+				Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+			})
+		})
+	})
+
+	context("Version", func() {
+		it.Before(func() {
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "0.33.2+git-f2cffc4.build-5562")
+				return nil
+			}
+		})
+
+		it("returns the version of pack", func() {
+			version, err := pack.Version()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(occam.PackVersion{Major: 0, Minor: 33, Patch: 2}))
+
+			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"version"}))
+		})
+
+		context("failure cases", func() {
+			context("when the executable fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some error output")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := pack.Version()
+					Expect(err).To(MatchError("failed to get pack version: exit status 1: some error output"))
+				})
+			})
+		})
+	})
+
 	context("WithNoColor", func() {
 		it.Before(func() {
 			pack = pack.WithNoColor()
@@ -422,6 +508,315 @@ func testPack(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when given optional env files", func() {
+			it("passes them to pack", func() {
+				_, _, err := pack.Build.
+					WithEnv(map[string]string{"SOME_VAR": "some-value"}).
+					WithEnvFiles("some.env", "other.env").
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"build", "myapp",
+					"--path", "/some/app/path",
+					"--env", "SOME_VAR=some-value",
+					"--env-file", "some.env",
+					"--env-file", "other.env",
+					"--cache",
+					"type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build",
+					"--cache",
+					"type=launch;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.launch",
+				}))
+			})
+		})
+
+		context("when given an optional descriptor", func() {
+			it("passes it to pack", func() {
+				_, _, err := pack.Build.WithDescriptor("/some/project.toml").Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"build", "myapp",
+					"--path", "/some/app/path",
+					"--descriptor", "/some/project.toml",
+					"--cache",
+					"type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build",
+					"--cache",
+					"type=launch;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.launch",
+				}))
+			})
+		})
+
+		context("when given an optional project descriptor", func() {
+			var (
+				descriptorPath string
+				descriptor     string
+			)
+
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					for i, arg := range execution.Args {
+						if arg == "--descriptor" {
+							descriptorPath = execution.Args[i+1]
+						}
+					}
+
+					content, err := os.ReadFile(descriptorPath)
+					if err != nil {
+						return err
+					}
+					descriptor = string(content)
+
+					return nil
+				}
+			})
+
+			it("writes it to a temporary project.toml and passes it to pack", func() {
+				_, _, err := pack.Build.
+					WithProjectDescriptor(occam.ProjectDescriptor{
+						Buildpacks: []occam.ProjectDescriptorBuildpack{{ID: "some-buildpack"}},
+					}).
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Base(descriptorPath)).To(Equal("project.toml"))
+				Expect(descriptor).To(ContainSubstring(`id = "some-buildpack"`))
+				Expect(descriptorPath).NotTo(BeAnExistingFile())
+			})
+
+			context("failure cases", func() {
+				context("when the descriptor is invalid", func() {
+					it("returns an error", func() {
+						_, _, err := pack.Build.
+							WithProjectDescriptor(occam.ProjectDescriptor{
+								Include: []string{"src/"},
+								Exclude: []string{"tests/"},
+							}).
+							Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError("failed to pack build: failed to encode project descriptor: include and exclude cannot both be set"))
+						Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+					})
+				})
+
+				context("when a buildpack URI is relative", func() {
+					it("returns an error", func() {
+						_, _, err := pack.Build.
+							WithProjectDescriptor(occam.ProjectDescriptor{
+								Buildpacks: []occam.ProjectDescriptorBuildpack{
+									{URI: "docker://some-registry/some-buildpack"},
+									{URI: "/some/buildpack/path"},
+									{URI: "some/buildpack/path"},
+								},
+							}).
+							Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError(`failed to pack build: project descriptor buildpack URI "some/buildpack/path" is relative: use an absolute path, or WriteProjectDescriptor to write the descriptor into the app`))
+						Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+					})
+				})
+			})
+		})
+
+		context("when given options that depend on the pack version", func() {
+			var (
+				packVersion string
+				executions  []pexec.Execution
+			)
+
+			it.Before(func() {
+				packVersion = "0.33.2+git-f2cffc4.build-5562"
+				executions = nil
+
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					executions = append(executions, execution)
+
+					if execution.Args[0] == "version" {
+						_, _ = fmt.Fprintln(execution.Stdout, packVersion)
+						return nil
+					}
+
+					_, _ = fmt.Fprintln(execution.Stdout, "some stdout output")
+					return nil
+				}
+			})
+
+			it("checks the pack version and passes the options", func() {
+				_, logs, err := pack.Build.
+					WithLifecycleImage("buildpacksio/lifecycle:0.20.0").
+					WithCreationTime(time.Unix(1700000000, 0)).
+					WithPreviousImage("myapp:previous").
+					WithTags("myapp:v1", "myapp:v1.2").
+					WithCacheImage("registry.example.com/myapp-cache").
+					WithDefaultProcess("web").
+					WithWorkspace("/app").
+					WithUID("1001").
+					WithUsernsHost().
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logs.String()).To(Equal("some stdout output\n"))
+
+				Expect(executions).To(HaveLen(2))
+				Expect(executions[0].Args).To(Equal([]string{"version"}))
+				Expect(executions[1].Args).To(Equal([]string{
+					"build", "myapp",
+					"--path", "/some/app/path",
+					"--lifecycle-image", "buildpacksio/lifecycle:0.20.0",
+					"--creation-time", "1700000000",
+					"--previous-image", "myapp:previous",
+					"--tag", "myapp:v1",
+					"--tag", "myapp:v1.2",
+					"--cache-image", "registry.example.com/myapp-cache",
+					"--default-process", "web",
+					"--workspace", "/app",
+					"--uid", "1001",
+					"--userns", "host",
+					"--cache",
+					"type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build",
+					"--cache",
+					"type=launch;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.launch",
+				}))
+			})
+
+			it("passes the default caches without checking the pack version for other options", func() {
+				_, _, err := pack.Build.WithGID("1000").Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(1))
+				Expect(executions[0].Args).To(ContainElements("--gid", "1000", "--cache"))
+			})
+
+			it("checks the pack version once for every build", func() {
+				build := pack.Build.WithWorkspace("/app")
+
+				_, _, err := build.Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = build.WithUID("1001").Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pack.Version()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(3))
+				Expect(executions[0].Args).To(Equal([]string{"version"}))
+				Expect(executions[1].Args[0]).To(Equal("build"))
+				Expect(executions[2].Args[0]).To(Equal("build"))
+			})
+
+			it("passes extensions and caches", func() {
+				_, _, err := pack.Build.
+					WithExtensions("some-extension").
+					WithCaches("type=build;format=bind;source=/some/cache").
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions[1].Args).To(Equal([]string{
+					"build", "myapp",
+					"--path", "/some/app/path",
+					"--extension", "some-extension",
+					"--cache", "type=build;format=bind;source=/some/cache",
+				}))
+			})
+
+			context("when pack is too old for the --cache flag", func() {
+				it.Before(func() {
+					packVersion = "0.25.0"
+				})
+
+				it("passes the default caches", func() {
+					_, _, err := pack.Build.Execute("myapp", "/some/app/path")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(executions).To(HaveLen(1))
+					Expect(executions[0].Args).To(ContainElement("--cache"))
+				})
+
+				context("when given the cache fallback", func() {
+					it("leaves the cache volume names to pack", func() {
+						_, _, err := pack.Build.WithCacheFallback().Execute("myapp", "/some/app/path")
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(executions[1].Args).To(Equal([]string{"build", "myapp", "--path", "/some/app/path"}))
+						Expect(executions[1].Env).To(ContainElement("PACK_VOLUME_KEY=myapp-volume"))
+					})
+				})
+			})
+
+			context("when the pack version cannot be found for the cache fallback", func() {
+				it.Before(func() {
+					packVersion = "unknown"
+				})
+
+				it("passes the default caches", func() {
+					_, _, err := pack.Build.WithCacheFallback().Execute("myapp", "/some/app/path")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(executions[1].Args).To(ContainElement("type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build"))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when pack is too old for an option", func() {
+					it.Before(func() {
+						packVersion = "v0.26.0"
+					})
+
+					it("returns an error naming the unsupported flags", func() {
+						_, _, err := pack.Build.
+							WithDefaultProcess("web").
+							WithCreationTime(time.Unix(1700000000, 0)).
+							WithUID("1001").
+							Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError("failed to pack build: pack 0.26.0 does not support --creation-time (requires 0.27.0), --uid (requires 0.31.0)"))
+						Expect(executions).To(HaveLen(1))
+					})
+				})
+
+				context("when pack is too old for extensions or caches", func() {
+					it.Before(func() {
+						packVersion = "0.25.0"
+					})
+
+					it("returns an error naming the unsupported flags", func() {
+						_, _, err := pack.Build.
+							WithExtensions("some-extension").
+							WithCaches("type=build;format=volume").
+							Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError("failed to pack build: pack 0.25.0 does not support --extension (requires 0.28.0), --cache (requires 0.26.0)"))
+
+						var capabilityErr occam.PackCapabilityError
+						Expect(errors.As(err, &capabilityErr)).To(BeTrue())
+						Expect(capabilityErr.Capabilities).To(Equal([]occam.PackCapability{occam.PackCapabilityExtension, occam.PackCapabilityCache}))
+						Expect(executions).To(HaveLen(1))
+					})
+				})
+
+				context("when the pack version cannot be parsed", func() {
+					it.Before(func() {
+						packVersion = "unknown"
+					})
+
+					it("returns an error", func() {
+						_, _, err := pack.Build.WithWorkspace("/app").Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError(`failed to pack build: failed to parse pack version "unknown"`))
+					})
+				})
+
+				context("when the pack version cannot be found", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stderr, "some error output")
+							return errors.New("exit status 1")
+						}
+					})
+
+					it("returns an error", func() {
+						_, _, err := pack.Build.WithWorkspace("/app").Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError("failed to pack build: failed to get pack version: exit status 1: some error output"))
+					})
+				})
+			})
+		})
+
 		context("when given optional trust-builder", func() {
 			it("returns an image with the given name and the build logs", func() {
 				image, logs, err := pack.Build.WithTrustBuilder().Execute("myapp", "/some/app/path")
@@ -551,6 +946,90 @@ func testPack(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when given a log writer", func() {
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprint(execution.Stdout, "some stdout ")
+					_, _ = fmt.Fprintln(execution.Stdout, "output")
+					_, _ = fmt.Fprint(execution.Stderr, "some stderr output\nother stderr output\n")
+					return nil
+				}
+			})
+
+			it("streams the output with a prefix on every line", func() {
+				writer := bytes.NewBuffer(nil)
+
+				_, logs, err := pack.Build.
+					WithLogWriter(writer).
+					WithLogPrefix("[myapp] ").
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(writer.String()).To(Equal("[myapp] some stdout output\n[myapp] some stderr output\n[myapp] other stderr output\n"))
+				Expect(logs.String()).To(Equal("some stdout output\nsome stderr output\nother stderr output\n"))
+			})
+
+			it("streams the output as is without a prefix", func() {
+				writer := bytes.NewBuffer(nil)
+
+				_, logs, err := pack.Build.WithLogWriter(writer).Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(writer.String()).To(Equal(logs.String()))
+			})
+		})
+
+		context("Caches", func() {
+			it("returns the default caches", func() {
+				caches, err := pack.Build.Caches("myapp")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(caches).To(Equal(occam.DefaultCaches("myapp")))
+			})
+
+			it("returns the caches given to the build", func() {
+				caches, err := pack.Build.
+					WithCaches("type=build;format=volume;name=some-volume").
+					WithCache(occam.NewBindCache(occam.CacheTypeLaunch, "/some/dir")).
+					WithAdditionalBuildArgs("--cache", "type=build;format=image;name=some-registry/some-cache").
+					Caches("myapp")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(caches).To(Equal([]occam.Cache{
+					occam.NewVolumeCache(occam.CacheTypeBuild, "some-volume"),
+					occam.NewBindCache(occam.CacheTypeLaunch, "/some/dir"),
+					occam.NewImageCache("some-registry/some-cache"),
+				}))
+			})
+
+			it("passes the caches given with WithCache to pack", func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					if execution.Args[0] == "version" {
+						_, _ = fmt.Fprintln(execution.Stdout, "0.33.2")
+					}
+					return nil
+				}
+
+				_, _, err := pack.Build.
+					WithCache(occam.NewBindCache(occam.CacheTypeBuild, "/some/dir")).
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"build", "myapp",
+					"--path", "/some/app/path",
+					"--cache", "type=build;format=bind;source=/some/dir",
+				}))
+			})
+
+			context("failure cases", func() {
+				context("when a cache cannot be parsed", func() {
+					it("returns an error", func() {
+						_, err := pack.Build.WithCaches("type=build").Caches("myapp")
+						Expect(err).To(MatchError(`failed to parse cache "type=build": missing name`))
+					})
+				})
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the executable fails", func() {
 				it.Before(func() {
@@ -580,48 +1059,415 @@ func testPack(t *testing.T, context spec.G, it spec.S) {
 				})
 			})
 		})
-	})
 
-	context("Builder", func() {
-		context("Inspect", func() {
-			context("when given no builder image name", func() {
-				it.Before(func() {
-					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-						_, _ = fmt.Fprintln(execution.Stdout, `{
-							"builder_name": "default-builder",
-							"trusted": true,
-							"default": true,
-							"local_info": {
-								"description": "some-builder-description",
-								"created_by": {
-									"name": "some-creator-name",
-									"version": "some-creator-version"
-								},
-								"stack": {
-									"id": "some-stack-id"
-								},
-								"lifecycle": {
-									"version": "some-lifecycle-version",
-									"buildpack_apis": {
-										"deprecated": [
-											"some-deprecated-api-version"
-										],
-										"supported": [
-											"some-api-version",
-											"other-api-version"
-										]
-									},
-									"platform_apis": {
-										"deprecated": [
-											"some-deprecated-api-version"
-										],
-										"supported": [
-											"some-api-version",
-											"other-api-version"
-										]
-									}
-								},
-								"run_images": [
+		context("WithBindings", func() {
+			var (
+				bindingsDir string
+				bindingType string
+			)
+
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					for i, arg := range execution.Args {
+						if arg == "--volume" && strings.HasSuffix(execution.Args[i+1], ":/platform/bindings:ro") {
+							bindingsDir = strings.TrimSuffix(execution.Args[i+1], ":/platform/bindings:ro")
+						}
+					}
+
+					content, err := os.ReadFile(filepath.Join(bindingsDir, "some-binding", "type"))
+					if err != nil {
+						return err
+					}
+					bindingType = string(content)
+
+					return nil
+				}
+			})
+
+			it("mounts the bindings into the build and removes them afterwards", func() {
+				_, _, err := pack.Build.
+					WithEnv(map[string]string{"SOME_VAR": "some-value"}).
+					WithVolumes("/some/volume:/some/volume").
+					WithBindings(occam.NewBinding("some-binding", "some-type")).
+					Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"build", "myapp", "--path", "/some/app/path",
+					"--env", "SERVICE_BINDING_ROOT=/platform/bindings",
+					"--env", "SOME_VAR=some-value",
+					"--volume", "/some/volume:/some/volume",
+					"--volume", fmt.Sprintf("%s:/platform/bindings:ro", bindingsDir),
+					"--cache",
+					"type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build",
+					"--cache",
+					"type=launch;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.launch",
+				}))
+				Expect(bindingType).To(Equal("some-type"))
+				Expect(bindingsDir).NotTo(BeADirectory())
+			})
+
+			context("failure cases", func() {
+				context("when a binding is invalid", func() {
+					it("returns an error", func() {
+						_, _, err := pack.Build.
+							WithBindings(occam.NewBinding("some-binding", "")).
+							Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError(`failed to pack build: invalid binding "some-binding": missing type`))
+						Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+					})
+				})
+			})
+		})
+
+		context("WithPublish", func() {
+			var (
+				registry     occam.Registry
+				built        v1.Image
+				dockerConfig string
+			)
+
+			it.Before(func() {
+				var err error
+				registry, err = occam.NewLocalRegistry().WithCleanup(t).Start()
+				Expect(err).NotTo(HaveOccurred())
+
+				built, err = random.Image(10, 2)
+				Expect(err).NotTo(HaveOccurred())
+
+				dockerConfig = ""
+				t.Setenv("DOCKER_CONFIG", t.TempDir())
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					if execution.Args[0] == "version" {
+						_, _ = fmt.Fprintln(execution.Stdout, "0.34.0")
+						return nil
+					}
+
+					_, _ = fmt.Fprintln(execution.Stdout, "some stdout output")
+
+					for _, env := range execution.Env {
+						if strings.HasPrefix(env, "DOCKER_CONFIG=") {
+							content, err := os.ReadFile(filepath.Join(strings.TrimPrefix(env, "DOCKER_CONFIG="), "config.json"))
+							if err != nil && !os.IsNotExist(err) {
+								return err
+							}
+							dockerConfig = string(content)
+						}
+					}
+
+					_, err := registry.Push(strings.TrimPrefix(execution.Args[1], registry.Host+"/"), built)
+					return err
+				}
+			})
+
+			it("publishes the image and inspects it through the registry", func() {
+				image, logs, err := pack.Build.WithNetwork("host").WithPublish(registry.Host).Execute("myapp", "/some/app/path")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logs.String()).To(Equal("some stdout output\n"))
+
+				configName, err := built.ConfigName()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(image.ID).To(Equal(configName.String()))
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"build", fmt.Sprintf("%s/myapp", registry.Host), "--path", "/some/app/path",
+					"--network", "host",
+					"--publish",
+					"--cache",
+					"type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build",
+					"--cache",
+					"type=launch;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.launch",
+				}))
+				Expect(dockerImageInspectClient.ExecuteCall.CallCount).To(Equal(0))
+				Expect(dockerConfig).To(BeEmpty())
+			})
+
+			context("when given registry credentials", func() {
+				it("gives them to pack in a docker config", func() {
+					_, _, err := pack.Build.
+						WithNetwork("host").
+						WithPublish(registry.Host).
+						WithRegistryAuth("some-user", "some-password").
+						Execute("myapp", "/some/app/path")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(dockerConfig).To(MatchJSON(fmt.Sprintf(`{"auths": {%q: {"auth": "c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ="}}}`, registry.Host)))
+				})
+
+				context("when given a docker config", func() {
+					var dir string
+
+					it.Before(func() {
+						dir = t.TempDir()
+						Expect(os.WriteFile(filepath.Join(dir, "config.json"), []byte(fmt.Sprintf(`{
+							"auths": {
+								"other.example.com": {"auth": "b3RoZXI6Y3JlZGVudGlhbHM="},
+								%q: {"auth": "b2xkOmNyZWRlbnRpYWxz"}
+							},
+							"credHelpers": {
+								"helped.example.com": "some-helper",
+								%q: "some-helper"
+							},
+							"credsStore": "some-store",
+							"experimental": "enabled"
+						}`, registry.Host, registry.Host)), 0600)).To(Succeed())
+					})
+
+					it("adds them to a copy of the docker config", func() {
+						_, _, err := pack.Build.
+							WithNetwork("host").
+							WithPublish(registry.Host).
+							WithDockerConfig(dir).
+							WithRegistryAuth("some-user", "some-password").
+							Execute("myapp", "/some/app/path")
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(executable.ExecuteCall.Receives.Execution.Env).NotTo(ContainElement("DOCKER_CONFIG=" + dir))
+						Expect(dockerConfig).To(MatchJSON(fmt.Sprintf(`{
+							"auths": {
+								"other.example.com": {"auth": "b3RoZXI6Y3JlZGVudGlhbHM="},
+								%q: {"auth": "c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ="}
+							},
+							"credHelpers": {
+								"helped.example.com": "some-helper",
+								"other.example.com": "some-store"
+							},
+							"experimental": "enabled"
+						}`, registry.Host)))
+
+						content, err := os.ReadFile(filepath.Join(dir, "config.json"))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(content)).To(ContainSubstring(`"credsStore": "some-store"`))
+					})
+				})
+
+				context("when the DOCKER_CONFIG environment variable is set", func() {
+					it("adds them to a copy of that docker config", func() {
+						dir := t.TempDir()
+						t.Setenv("DOCKER_CONFIG", dir)
+						Expect(os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths": {"other.example.com": {"auth": "b3RoZXI6Y3JlZGVudGlhbHM="}}}`), 0600)).To(Succeed())
+
+						_, _, err := pack.Build.
+							WithNetwork("host").
+							WithPublish(registry.Host).
+							WithRegistryAuth("some-user", "some-password").
+							Execute("myapp", "/some/app/path")
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(dockerConfig).To(MatchJSON(fmt.Sprintf(`{
+							"auths": {
+								"other.example.com": {"auth": "b3RoZXI6Y3JlZGVudGlhbHM="},
+								%q: {"auth": "c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ="}
+							}
+						}`, registry.Host)))
+					})
+				})
+
+				context("when the docker config is malformed", func() {
+					it("returns an error", func() {
+						dir := t.TempDir()
+						Expect(os.WriteFile(filepath.Join(dir, "config.json"), []byte("%%%"), 0600)).To(Succeed())
+
+						_, _, err := pack.Build.
+							WithNetwork("host").
+							WithPublish(registry.Host).
+							WithDockerConfig(dir).
+							WithRegistryAuth("some-user", "some-password").
+							Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError(ContainSubstring("failed to parse docker config")))
+					})
+				})
+			})
+
+			context("when given a docker config", func() {
+				it("gives it to pack", func() {
+					dir := t.TempDir()
+					Expect(os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths": {}}`), 0600)).To(Succeed())
+
+					_, _, err := pack.Build.
+						WithNetwork("host").
+						WithPublish(registry.Host).
+						WithDockerConfig(dir).
+						Execute("myapp", "/some/app/path")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(executable.ExecuteCall.Receives.Execution.Env).To(ContainElement("DOCKER_CONFIG=" + dir))
+					Expect(dockerConfig).To(MatchJSON(`{"auths": {}}`))
+				})
+			})
+
+			context("when given insecure registries", func() {
+				it("allows pack to reach them over HTTP", func() {
+					_, _, err := pack.Build.
+						WithNetwork("host").
+						WithPublish("").
+						WithInsecureRegistries(registry.Host).
+						Execute(registry.Ref("myapp"), "/some/app/path")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(executable.ExecuteCall.Receives.Execution.Args).To(ContainElements(
+						"--publish",
+						"--insecure-registry", registry.Host,
+					))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when pack does not support insecure registries", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stdout, "0.33.2")
+							return nil
+						}
+					})
+
+					it("returns an error", func() {
+						_, _, err := pack.Build.
+							WithNetwork("host").
+							WithPublish("").
+							WithInsecureRegistries(registry.Host).
+							Execute(registry.Ref("myapp"), "/some/app/path")
+						Expect(err).To(MatchError("failed to pack build: pack 0.33.2 does not support --insecure-registry (requires 0.34.0)"))
+						Expect(executable.ExecuteCall.CallCount).To(Equal(1))
+					})
+				})
+
+				context("when publishing to a localhost registry without the host network", func() {
+					it("returns an error", func() {
+						_, _, err := pack.Build.WithPublish(registry.Host).Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError(fmt.Sprintf(`failed to pack build: cannot publish to %s: the registry is only reachable from containers on the host network, use WithNetwork("host")`, registry.Host)))
+						Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+					})
+				})
+
+				context("when the image was not published", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = nil
+					})
+
+					it("returns an error", func() {
+						_, _, err := pack.Build.WithNetwork("host").WithPublish(registry.Host).Execute("myapp", "/some/app/path")
+						Expect(err).To(MatchError(ContainSubstring("failed to pack build: failed to inspect published image")))
+					})
+				})
+			})
+		})
+
+		context("ExecuteToOCILayout", func() {
+			var (
+				layoutDir string
+				built     v1.Image
+			)
+
+			it.Before(func() {
+				layoutDir = filepath.Join(t.TempDir(), "layout")
+
+				var err error
+				built, err = random.Image(10, 2)
+				Expect(err).NotTo(HaveOccurred())
+
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stdout, "some stdout output")
+
+					path, err := layout.Write(layoutDir, empty.Index)
+					if err != nil {
+						return err
+					}
+
+					return path.AppendImage(built)
+				}
+			})
+
+			it("builds the app into the OCI layout and returns its image", func() {
+				image, logs, err := pack.Build.ExecuteToOCILayout("myapp", "/some/app/path", layoutDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logs.String()).To(Equal("some stdout output\n"))
+
+				expected, err := built.Digest()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(image.Digest()).To(Equal(expected))
+
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"build", fmt.Sprintf("oci:%s", layoutDir), "--path", "/some/app/path",
+					"--cache",
+					"type=build;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.build",
+					"--cache",
+					"type=launch;format=volume;name=pack-cache-myapp_latest-c48abba4d0f8.launch",
+				}))
+				Expect(executable.ExecuteCall.Receives.Execution.Env).To(ContainElement("PACK_VOLUME_KEY=myapp-volume"))
+				Expect(dockerImageInspectClient.ExecuteCall.CallCount).To(Equal(0))
+			})
+
+			context("failure cases", func() {
+				context("when the executable fails", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprintln(execution.Stderr, "some stderr output")
+							return errors.New("failed to execute")
+						}
+					})
+
+					it("returns an error and the build logs", func() {
+						_, logs, err := pack.Build.ExecuteToOCILayout("myapp", "/some/app/path", layoutDir)
+						Expect(err).To(MatchError("failed to pack build: failed to execute\n\nOutput:\nsome stderr output\n"))
+						Expect(logs.String()).To(Equal("some stderr output\n"))
+					})
+				})
+
+				context("when pack does not write an OCI layout", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = nil
+					})
+
+					it("returns an error", func() {
+						_, _, err := pack.Build.ExecuteToOCILayout("myapp", "/some/app/path", layoutDir)
+						Expect(err).To(MatchError(ContainSubstring("failed to pack build: failed to read OCI layout")))
+					})
+				})
+			})
+		})
+	})
+
+	context("Builder", func() {
+		context("Inspect", func() {
+			context("when given no builder image name", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stdout, `{
+							"builder_name": "default-builder",
+							"trusted": true,
+							"default": true,
+							"local_info": {
+								"description": "some-builder-description",
+								"created_by": {
+									"name": "some-creator-name",
+									"version": "some-creator-version"
+								},
+								"stack": {
+									"id": "some-stack-id"
+								},
+								"lifecycle": {
+									"version": "some-lifecycle-version",
+									"buildpack_apis": {
+										"deprecated": [
+											"some-deprecated-api-version"
+										],
+										"supported": [
+											"some-api-version",
+											"other-api-version"
+										]
+									},
+									"platform_apis": {
+										"deprecated": [
+											"some-deprecated-api-version"
+										],
+										"supported": [
+											"some-api-version",
+											"other-api-version"
+										]
+									}
+								},
+								"run_images": [
 									{
 										"name": "some-run-image"
 									}
@@ -918,6 +1764,95 @@ func testPack(t *testing.T, context spec.G, it spec.S) {
 				})
 			})
 
+			context("when given recorded pack output", func() {
+				var fixture string
+
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						content, err := os.ReadFile(filepath.Join("testdata", "pack-builder-inspect", fixture))
+						if err != nil {
+							return err
+						}
+
+						_, err = execution.Stdout.Write(content)
+						return err
+					}
+				})
+
+				context("for a stack based builder with run image mirrors", func() {
+					it.Before(func() {
+						fixture = "builder-jammy-base.json"
+					})
+
+					it("returns the run image and its mirrors", func() {
+						builder, err := pack.Builder.Inspect.Execute("paketobuildpacks/builder-jammy-base")
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(builder.LocalInfo).To(Equal(occam.BuilderInfo{}))
+						Expect(builder.RemoteInfo.Stack.ID).To(Equal("io.buildpacks.stacks.jammy"))
+						Expect(builder.RemoteInfo.RunImages).To(Equal([]occam.BuilderInfoRunImage{
+							{Name: "registry.example.com/paketobuildpacks/run-jammy-base:latest", UserConfigured: true},
+							{Name: "index.docker.io/paketobuildpacks/run-jammy-base:latest"},
+							{Name: "gcr.io/paketo-buildpacks/run-jammy-base:latest"},
+						}))
+						Expect(builder.RemoteInfo.RunImage()).To(Equal("index.docker.io/paketobuildpacks/run-jammy-base:latest"))
+						Expect(builder.RemoteInfo.RunImageMirrors()).To(Equal([]string{
+							"registry.example.com/paketobuildpacks/run-jammy-base:latest",
+							"gcr.io/paketo-buildpacks/run-jammy-base:latest",
+						}))
+						Expect(builder.RemoteInfo.BuildImage).To(Equal(occam.BuilderInfoBuildImage{}))
+						Expect(builder.RemoteInfo.Targets).To(BeEmpty())
+						Expect(builder.RemoteInfo.Extensions).To(BeEmpty())
+					})
+				})
+
+				context("for a target based builder with extensions", func() {
+					it.Before(func() {
+						fixture = "ubi-8-builder.json"
+					})
+
+					it("returns the build image, targets and extensions", func() {
+						builder, err := pack.Builder.Inspect.Execute("paketobuildpacks/builder-ubi8-base")
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(builder.RemoteInfo).To(Equal(occam.BuilderInfo{}))
+						Expect(builder.LocalInfo.BuildImage).To(Equal(occam.BuilderInfoBuildImage{
+							Name: "index.docker.io/paketobuildpacks/build-ubi8-base:latest",
+						}))
+						Expect(builder.LocalInfo.RunImage()).To(Equal("index.docker.io/paketobuildpacks/run-ubi8-base:latest"))
+						Expect(builder.LocalInfo.RunImageMirrors()).To(BeEmpty())
+						Expect(builder.LocalInfo.Targets).To(Equal([]occam.BuilderInfoTarget{
+							{
+								OS:      "linux",
+								Arch:    "amd64",
+								Distros: []occam.BuilderInfoTargetDistro{{Name: "rhel", Version: "8.10"}},
+							},
+							{
+								OS:          "linux",
+								Arch:        "arm64",
+								ArchVariant: "v8",
+								Distros:     []occam.BuilderInfoTargetDistro{{Name: "rhel", Version: "8.10"}},
+							},
+						}))
+						Expect(builder.LocalInfo.Extensions).To(Equal([]occam.BuilderInfoBuildpack{
+							{
+								ID:       "paketo-community/ubi-nodejs-extension",
+								Name:     "Paketo Node.js UBI Extension",
+								Version:  "1.4.2",
+								Homepage: "https://github.com/paketo-community/ubi-nodejs-extension",
+							},
+						}))
+						Expect(builder.LocalInfo.OrderExtensions).To(Equal([]occam.BuilderInfoDetectionOrder{
+							{
+								Buildpacks: []occam.BuilderInfoDetectionOrderBuildpack{
+									{ID: "paketo-community/ubi-nodejs-extension", Version: "1.4.2", Optional: true},
+								},
+							},
+						}))
+					})
+				})
+			})
+
 			context("failure cases", func() {
 				context("when the pack executable fails", func() {
 					it.Before(func() {
@@ -950,5 +1885,343 @@ func testPack(t *testing.T, context spec.G, it spec.S) {
 				})
 			})
 		})
+
+		context("Create", func() {
+			var (
+				builderToml             string
+				registrar               *cleanupRegistrar
+				dockerImageRemoveClient *fakes.DockerImageRemoveClient
+				fakeLocalFetcher        *fakes.LocalFetcher
+				fakeCacheManager        *fakes.CacheManager
+				config                  occam.BuilderConfig
+			)
+
+			it.Before(func() {
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					switch execution.Args[1] {
+					case "create":
+						content, err := os.ReadFile(execution.Args[4])
+						if err != nil {
+							return err
+						}
+						builderToml = string(content)
+						_, _ = fmt.Fprintln(execution.Stdout, "some create output")
+					case "inspect":
+						_, _ = fmt.Fprintln(execution.Stdout, `{
+							"builder_name": "some-builder",
+							"local_info": {
+								"stack": {
+									"id": "some-stack-id"
+								}
+							}
+						}`)
+					}
+					return nil
+				}
+
+				registrar = &cleanupRegistrar{}
+				dockerImageRemoveClient = &fakes.DockerImageRemoveClient{}
+
+				fakeLocalFetcher = &fakes.LocalFetcher{}
+				fakeLocalFetcher.GetCall.Returns.String = "/cache/some-local-buildpack.cnb"
+				fakeCacheManager = &fakes.CacheManager{}
+
+				pack = pack.WithDockerImageRemoveClient(dockerImageRemoveClient)
+
+				config = occam.BuilderConfig{
+					Description:      "some-description",
+					StackID:          "some-stack-id",
+					BuildImage:       "some-build-image",
+					RunImage:         "some-run-image",
+					RunImageMirrors:  []string{"some-run-image-mirror"},
+					LifecycleVersion: "0.20.0",
+					Buildpacks: []occam.BuilderConfigBuildpack{
+						{URI: "docker://some-buildpack-image"},
+						{Ref: t.TempDir()},
+					},
+					Extensions: []occam.BuilderConfigBuildpack{
+						{URI: "urn:cnb:registry:some-extension"},
+					},
+					Order: []occam.BuilderConfigOrder{
+						{
+							Group: []occam.BuilderConfigOrderGroup{
+								{ID: "some-buildpack", Version: "1.2.3"},
+								{ID: "other-buildpack", Optional: true},
+							},
+						},
+					},
+					OrderExtensions: []occam.BuilderConfigOrder{
+						{
+							Group: []occam.BuilderConfigOrderGroup{
+								{ID: "some-extension"},
+							},
+						},
+					},
+				}
+			})
+
+			it("creates the builder and returns it inspected", func() {
+				store := occam.NewBuildpackStore().
+					WithLocalFetcher(fakeLocalFetcher).
+					WithCacheManager(fakeCacheManager)
+
+				builder, err := pack.Builder.Create.
+					WithBuildpackStore(store).
+					WithCleanup(registrar).
+					WithPullPolicy("if-not-present").
+					Execute("some-builder", config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(builder).To(Equal(occam.Builder{
+					BuilderName: "some-builder",
+					LocalInfo: occam.BuilderInfo{
+						Stack: occam.BuilderInfoStack{
+							ID: "some-stack-id",
+						},
+					},
+				}))
+
+				Expect(executable.ExecuteCall.CallCount).To(Equal(2))
+				Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+					"builder", "inspect", "some-builder",
+					"--output", "json",
+				}))
+
+				Expect(builderToml).To(Equal(`description = "some-description"
+
+[[buildpacks]]
+  uri = "docker://some-buildpack-image"
+
+[[buildpacks]]
+  uri = "/cache/some-local-buildpack.cnb"
+
+[[extensions]]
+  uri = "urn:cnb:registry:some-extension"
+
+[[order]]
+
+  [[order.group]]
+    id = "some-buildpack"
+    version = "1.2.3"
+
+  [[order.group]]
+    id = "other-buildpack"
+    optional = true
+
+[[order-extensions]]
+
+  [[order-extensions.group]]
+    id = "some-extension"
+
+[stack]
+  id = "some-stack-id"
+  build-image = "some-build-image"
+  run-image = "some-run-image"
+  run-image-mirrors = ["some-run-image-mirror"]
+
+[build]
+  image = "some-build-image"
+
+[run]
+
+  [[run.images]]
+    image = "some-run-image"
+    mirrors = ["some-run-image-mirror"]
+
+[lifecycle]
+  version = "0.20.0"
+`))
+
+				Expect(registrar.funcs).To(HaveLen(1))
+				Expect(dockerImageRemoveClient.ExecuteCall.CallCount).To(Equal(0))
+
+				registrar.funcs[0]()
+				Expect(dockerImageRemoveClient.ExecuteCall.Receives.Ref).To(Equal("some-builder"))
+			})
+
+			it("passes the create arguments to pack", func() {
+				var createArgs []string
+				stub := executable.ExecuteCall.Stub
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					if execution.Args[1] == "create" {
+						createArgs = execution.Args
+					}
+					return stub(execution)
+				}
+
+				config.Buildpacks = config.Buildpacks[:1]
+				_, err := pack.Builder.Create.
+					WithPullPolicy("never").
+					Execute("some-builder", config)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(createArgs).To(HaveLen(7))
+				Expect(createArgs[:4]).To(Equal([]string{"builder", "create", "some-builder", "--config"}))
+				Expect(createArgs[4]).To(HaveSuffix("builder.toml"))
+				Expect(createArgs[5:]).To(Equal([]string{"--pull-policy", "never"}))
+				Expect(createArgs[4]).NotTo(BeAnExistingFile())
+			})
+
+			context("failure cases", func() {
+				context("when a buildpack ref is given without a store", func() {
+					it("returns an error", func() {
+						_, err := pack.Builder.Create.Execute("some-builder", config)
+						Expect(err).To(MatchError(fmt.Sprintf("failed to resolve %q: no buildpack store configured", config.Buildpacks[1].Ref)))
+					})
+				})
+
+				context("when the buildpack store fails", func() {
+					it.Before(func() {
+						fakeLocalFetcher.GetCall.Returns.Error = errors.New("some store error")
+					})
+
+					it("returns an error", func() {
+						store := occam.NewBuildpackStore().
+							WithLocalFetcher(fakeLocalFetcher).
+							WithCacheManager(fakeCacheManager)
+
+						_, err := pack.Builder.Create.WithBuildpackStore(store).Execute("some-builder", config)
+						Expect(err).To(MatchError(fmt.Sprintf("failed to resolve %q: some store error", config.Buildpacks[1].Ref)))
+					})
+				})
+
+				context("when the temp output cannot be created", func() {
+					it("returns an error", func() {
+						config.Buildpacks = nil
+						_, err := pack.Builder.Create.
+							WithTempOutput(func(string, string) (string, error) { return "", errors.New("some temp error") }).
+							Execute("some-builder", config)
+						Expect(err).To(MatchError("some temp error"))
+					})
+				})
+
+				context("when pack builder create fails", func() {
+					it.Before(func() {
+						executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							_, _ = fmt.Fprint(execution.Stdout, "some failure message")
+							return errors.New("some error")
+						}
+					})
+
+					it("returns an error and registers no cleanup", func() {
+						config.Buildpacks = nil
+						_, err := pack.Builder.Create.WithCleanup(registrar).Execute("some-builder", config)
+						Expect(err).To(MatchError("failed to pack builder create: some error\n\nOutput:\nsome failure message"))
+						Expect(registrar.funcs).To(BeEmpty())
+					})
+				})
+			})
+		})
 	})
+
+	context("Buildpack", func() {
+		context("Inspect", func() {
+			var labels map[string]string
+
+			it.Before(func() {
+				labels = map[string]string{
+					"io.buildpacks.buildpackage.metadata": `{"id": "some-org/some-composite", "version": "1.2.3"}`,
+					"io.buildpacks.buildpack.layers": `{
+						"some-org/some-composite": {
+							"1.2.3": {
+								"api": "0.10",
+								"order": [{"group": [{"id": "some-org/some-component", "version": "4.5.6", "optional": true}]}],
+								"layerDiffID": "sha256:composite-diff-id"
+							}
+						},
+						"some-org/some-component": {
+							"4.5.6": {
+								"api": "0.10",
+								"targets": [{"os": "linux", "arch": "arm64"}],
+								"layerDiffID": "sha256:component-diff-id"
+							}
+						}
+					}`,
+				}
+			})
+
+			context("when given a buildpack image", func() {
+				it.Before(func() {
+					dockerImageInspectClient.ExecuteCall.Returns.Image = occam.Image{Labels: labels}
+				})
+
+				it("returns the buildpack package", func() {
+					pkg, err := pack.Buildpack.Inspect.Execute("docker://some-registry/some-composite:1.2.3")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(pkg).To(Equal(occam.BuildpackPackage{
+						ID:      "some-org/some-composite",
+						Version: "1.2.3",
+						Buildpacks: []occam.BuildpackPackageBuildpack{
+							{
+								ID:      "some-org/some-component",
+								Version: "4.5.6",
+								API:     "0.10",
+								Targets: []occam.BuildpackPackageTarget{
+									{OS: "linux", Arch: "arm64"},
+								},
+								LayerDiffID: "sha256:component-diff-id",
+							},
+							{
+								ID:      "some-org/some-composite",
+								Version: "1.2.3",
+								API:     "0.10",
+								Order: []occam.BuildpackPackageOrder{
+									{Group: []occam.BuildpackPackageOrderGroup{{ID: "some-org/some-component", Version: "4.5.6", Optional: true}}},
+								},
+								LayerDiffID: "sha256:composite-diff-id",
+							},
+						},
+					}))
+
+					Expect(pkg.Dependencies()).To(Equal(pkg.Buildpacks[:1]))
+					Expect(dockerImageInspectClient.ExecuteCall.Receives.Ref).To(Equal("some-registry/some-composite:1.2.3"))
+				})
+			})
+
+			context("when given a .cnb file", func() {
+				it("returns the buildpack package", func() {
+					path := filepath.Join(t.TempDir(), "some-composite.cnb")
+					Expect(writeBuildpackPackage(path, labels)).To(Succeed())
+
+					pkg, err := pack.Buildpack.Inspect.Execute(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(pkg.ID).To(Equal("some-org/some-composite"))
+					Expect(pkg.Buildpacks).To(HaveLen(2))
+
+					Expect(dockerImageInspectClient.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the image cannot be inspected", func() {
+					it.Before(func() {
+						dockerImageInspectClient.ExecuteCall.Returns.Error = errors.New("some error")
+					})
+
+					it("returns an error", func() {
+						_, err := pack.Buildpack.Inspect.Execute("some-image")
+						Expect(err).To(MatchError("failed to inspect buildpack some-image: some error"))
+					})
+				})
+
+				context("when the image is not a buildpack", func() {
+					it.Before(func() {
+						dockerImageInspectClient.ExecuteCall.Returns.Image = occam.Image{}
+					})
+
+					it("returns an error", func() {
+						_, err := pack.Buildpack.Inspect.Execute("some-image")
+						Expect(err).To(MatchError("failed to inspect buildpack some-image: image is not a buildpack package: missing io.buildpacks.buildpackage.metadata label"))
+					})
+				})
+			})
+		})
+	})
+}
+
+type cleanupRegistrar struct {
+	funcs []func()
+}
+
+func (r *cleanupRegistrar) Cleanup(f func()) {
+	r.funcs = append(r.funcs, f)
 }
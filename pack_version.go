@@ -0,0 +1,193 @@
+package occam
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// PackVersion is the version of the pack CLI, as reported by `pack version`.
+type PackVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParsePackVersion parses the output of `pack version`, such as
+// "0.33.2+git-f2cffc4.build-5562".
+func ParsePackVersion(output string) (PackVersion, error) {
+	version := strings.TrimPrefix(strings.TrimSpace(output), "v")
+	version = strings.SplitN(version, "+", 2)[0]
+	version = strings.SplitN(version, "-", 2)[0]
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return PackVersion{}, fmt.Errorf("failed to parse pack version %q", strings.TrimSpace(output))
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return PackVersion{}, fmt.Errorf("failed to parse pack version %q", strings.TrimSpace(output))
+		}
+
+		numbers[i] = number
+	}
+
+	return PackVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+func (v PackVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func (v PackVersion) LessThan(other PackVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+
+	return v.Patch < other.Patch
+}
+
+// Supports reports whether this version of pack has the given capability.
+// Capabilities missing from the table are assumed to be supported.
+func (v PackVersion) Supports(capability PackCapability) bool {
+	return !v.LessThan(packCapabilities[capability].version)
+}
+
+// PackCapability is a feature of the pack CLI that is not available in every
+// version, usually a `pack build` flag.
+type PackCapability string
+
+const (
+	PackCapabilityCache            PackCapability = "cache"
+	PackCapabilityCacheImage       PackCapability = "cache-image"
+	PackCapabilityCreationTime     PackCapability = "creation-time"
+	PackCapabilityDefaultProcess   PackCapability = "default-process"
+	PackCapabilityExtension        PackCapability = "extension"
+	PackCapabilityInsecureRegistry PackCapability = "insecure-registry"
+	PackCapabilityLifecycleImage   PackCapability = "lifecycle-image"
+	PackCapabilityPreviousImage    PackCapability = "previous-image"
+	PackCapabilityTag              PackCapability = "tag"
+	PackCapabilityUID              PackCapability = "uid"
+	PackCapabilityUserns           PackCapability = "userns"
+	PackCapabilityWorkspace        PackCapability = "workspace"
+)
+
+type packCapability struct {
+	flag    string
+	version PackVersion
+}
+
+// packCapabilities lists the `pack build` flag of each capability, and the
+// first pack version with it.
+var packCapabilities = map[PackCapability]packCapability{
+	PackCapabilityCache:            {"--cache", PackVersion{0, 26, 0}},
+	PackCapabilityCacheImage:       {"--cache-image", PackVersion{0, 15, 0}},
+	PackCapabilityCreationTime:     {"--creation-time", PackVersion{0, 27, 0}},
+	PackCapabilityDefaultProcess:   {"--default-process", PackVersion{0, 18, 0}},
+	PackCapabilityExtension:        {"--extension", PackVersion{0, 28, 0}},
+	PackCapabilityInsecureRegistry: {"--insecure-registry", PackVersion{0, 34, 0}},
+	PackCapabilityLifecycleImage:   {"--lifecycle-image", PackVersion{0, 23, 0}},
+	PackCapabilityPreviousImage:    {"--previous-image", PackVersion{0, 17, 0}},
+	PackCapabilityTag:              {"--tag", PackVersion{0, 24, 0}},
+	PackCapabilityUID:              {"--uid", PackVersion{0, 31, 0}},
+	PackCapabilityUserns:           {"--userns", PackVersion{0, 30, 0}},
+	PackCapabilityWorkspace:        {"--workspace", PackVersion{0, 20, 0}},
+}
+
+// flag returns the `pack build` flag of the capability, or its name when it
+// is missing from the table.
+func (c PackCapability) flag() string {
+	if capability, ok := packCapabilities[c]; ok {
+		return capability.flag
+	}
+
+	return string(c)
+}
+
+// PackCapabilityError is returned when the installed pack lacks capabilities
+// a build requires.
+type PackCapabilityError struct {
+	Version      PackVersion
+	Capabilities []PackCapability
+}
+
+func (e PackCapabilityError) Error() string {
+	var unsupported []string
+	for _, capability := range e.Capabilities {
+		unsupported = append(unsupported, fmt.Sprintf("%s (requires %s)", capability.flag(), packCapabilities[capability].version))
+	}
+
+	return fmt.Sprintf("pack %s does not support %s", e.Version, strings.Join(unsupported, ", "))
+}
+
+// require returns a PackCapabilityError if any of the capabilities is not
+// supported.
+func (v PackVersion) require(capabilities ...PackCapability) error {
+	var unsupported []PackCapability
+	for _, capability := range capabilities {
+		if !v.Supports(capability) {
+			unsupported = append(unsupported, capability)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return PackCapabilityError{Version: v, Capabilities: unsupported}
+	}
+
+	return nil
+}
+
+// packVersionCache holds the version of pack once it has been detected, and is
+// shared by the copies of the Pack it belongs to, so that pack is not asked
+// for its version on every build.
+type packVersionCache struct {
+	mutex   sync.Mutex
+	version *PackVersion
+}
+
+func (c *packVersionCache) get(executable Executable) (PackVersion, error) {
+	if c == nil {
+		return detectPackVersion(executable)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.version != nil {
+		return *c.version, nil
+	}
+
+	version, err := detectPackVersion(executable)
+	if err != nil {
+		return PackVersion{}, err
+	}
+	c.version = &version
+
+	return version, nil
+}
+
+func detectPackVersion(executable Executable) (PackVersion, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	err := executable.Execute(pexec.Execution{
+		Args:   []string{"version"},
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return PackVersion{}, fmt.Errorf("failed to get pack version: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return ParsePackVersion(stdout.String())
+}
@@ -0,0 +1,68 @@
+package occam_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/occam"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPackVersion(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParsePackVersion", func() {
+		it("parses release versions", func() {
+			version, err := occam.ParsePackVersion("0.33.2+git-f2cffc4.build-5562\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(occam.PackVersion{Major: 0, Minor: 33, Patch: 2}))
+			Expect(version.String()).To(Equal("0.33.2"))
+		})
+
+		it("parses prefixed and pre-release versions", func() {
+			version, err := occam.ParsePackVersion("v0.34.0-rc1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(occam.PackVersion{Major: 0, Minor: 34, Patch: 0}))
+		})
+
+		context("failure cases", func() {
+			context("when the output is not a version", func() {
+				it("returns an error", func() {
+					_, err := occam.ParsePackVersion("some stdout output")
+					Expect(err).To(MatchError(`failed to parse pack version "some stdout output"`))
+				})
+			})
+
+			context("when a part of the version is not a number", func() {
+				it("returns an error", func() {
+					_, err := occam.ParsePackVersion("0.x.1")
+					Expect(err).To(MatchError(`failed to parse pack version "0.x.1"`))
+				})
+			})
+		})
+	})
+
+	context("LessThan", func() {
+		it("compares major, minor and patch versions in turn", func() {
+			version := occam.PackVersion{Major: 0, Minor: 30, Patch: 1}
+
+			Expect(version.LessThan(occam.PackVersion{Major: 1})).To(BeTrue())
+			Expect(version.LessThan(occam.PackVersion{Major: 0, Minor: 31})).To(BeTrue())
+			Expect(version.LessThan(occam.PackVersion{Major: 0, Minor: 30, Patch: 2})).To(BeTrue())
+			Expect(version.LessThan(version)).To(BeFalse())
+			Expect(version.LessThan(occam.PackVersion{Major: 0, Minor: 29, Patch: 9})).To(BeFalse())
+		})
+	})
+
+	context("Supports", func() {
+		it("reports whether the version has the capability", func() {
+			version := occam.PackVersion{Major: 0, Minor: 27, Patch: 0}
+
+			Expect(version.Supports(occam.PackCapabilityCache)).To(BeTrue())
+			Expect(version.Supports(occam.PackCapabilityCreationTime)).To(BeTrue())
+			Expect(version.Supports(occam.PackCapabilityExtension)).To(BeFalse())
+			Expect(version.Supports(occam.PackCapability("some-unknown-capability"))).To(BeTrue())
+		})
+	})
+}
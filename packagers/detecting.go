@@ -0,0 +1,259 @@
+package packagers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/paketo-buildpacks/packit/v2/fs"
+)
+
+// Detection records which packager Detecting chose for a buildpack, and why.
+type Detection struct {
+	Packager string
+	Reason   string
+}
+
+// Detecting is a packager that inspects the source of each buildpack and
+// dispatches to Jam, Libpak or LibpakTools, so that a single
+// occam.BuildpackStore can package both packit and libpak buildpacks.
+//
+// The packager is chosen from, in order:
+//   - the go.mod requirements: libpak/v2 uses LibpakTools, libpak uses Libpak
+//     and packit uses Jam,
+//   - an extension.toml or scripts/build.sh, which are packaged by Jam,
+//   - the tools invoked by the Makefile.
+type Detecting struct {
+	jam         Jam
+	libpak      Libpak
+	libpakTools LibpakTools
+	stdout      io.Writer
+}
+
+func NewDetecting() Detecting {
+	return Detecting{
+		jam:         NewJam(),
+		libpak:      NewLibpak(),
+		libpakTools: NewLibpakTools(),
+		stdout:      os.Stdout,
+	}
+}
+
+func (d Detecting) WithJam(jam Jam) Detecting {
+	d.jam = jam
+	return d
+}
+
+func (d Detecting) WithLibpak(libpak Libpak) Detecting {
+	d.libpak = libpak
+	return d
+}
+
+func (d Detecting) WithLibpakTools(libpakTools LibpakTools) Detecting {
+	d.libpakTools = libpakTools
+	return d
+}
+
+// WithOutput sets where the chosen packager is reported, and where the output
+// of the packagers is written.
+func (d Detecting) WithOutput(output io.Writer) Detecting {
+	d.stdout = output
+	d.jam = d.jam.WithOutput(output)
+	d.libpak = d.libpak.WithOutput(output)
+	d.libpakTools = d.libpakTools.WithOutput(output)
+	return d
+}
+
+// WithTargets sets the targets that each of the packagers packages the
+// buildpack for.
+func (d Detecting) WithTargets(targets ...string) Detecting {
+	d.jam = d.jam.WithTargets(targets...)
+	d.libpak = d.libpak.WithTargets(targets...)
+	d.libpakTools = d.libpakTools.WithTargets(targets...)
+	return d
+}
+
+// WithContext sets the context that stops each of the packagers.
+func (d Detecting) WithContext(ctx context.Context) Detecting {
+	d.jam = d.jam.WithContext(ctx)
+	d.libpak = d.libpak.WithContext(ctx)
+	d.libpakTools = d.libpakTools.WithContext(ctx)
+	return d
+}
+
+func (d Detecting) Execute(buildpackDir, output, version string, offline bool) error {
+	packager, err := d.choose(buildpackDir)
+	if err != nil {
+		return err
+	}
+
+	return packager.Execute(buildpackDir, output, version, offline)
+}
+
+// Package is like Execute, but also describes the packaged buildpack.
+func (d Detecting) Package(buildpackDir, output, version string, offline bool) (Result, error) {
+	packager, err := d.choose(buildpackDir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return packager.Package(buildpackDir, output, version, offline)
+}
+
+type resultPackager interface {
+	Execute(buildpackDir, output, version string, offline bool) error
+	Package(buildpackDir, output, version string, offline bool) (Result, error)
+}
+
+func (d Detecting) choose(buildpackDir string) (resultPackager, error) {
+	detection, err := d.Detect(buildpackDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(d.stdout, "Packaging %s with %s: %s\n", buildpackDir, detection.Packager, detection.Reason)
+
+	switch detection.Packager {
+	case "libpak-tools":
+		return d.libpakTools, nil
+	case "libpak":
+		return d.libpak, nil
+	default:
+		return d.jam, nil
+	}
+}
+
+// Detect reports which packager would be used for the buildpack.
+func (d Detecting) Detect(buildpackDir string) (Detection, error) {
+	requirements, err := goModRequirements(filepath.Join(buildpackDir, "go.mod"))
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to detect packager: %w", err)
+	}
+
+	for _, candidate := range []struct {
+		module   string
+		packager string
+	}{
+		{"github.com/paketo-buildpacks/libpak/v2", "libpak-tools"},
+		{"github.com/paketo-buildpacks/libpak", "libpak"},
+		{"github.com/paketo-buildpacks/packit/v2", "jam"},
+		{"github.com/paketo-buildpacks/packit", "jam"},
+	} {
+		if requirements[candidate.module] {
+			return Detection{
+				Packager: candidate.packager,
+				Reason:   fmt.Sprintf("go.mod requires %s", candidate.module),
+			}, nil
+		}
+	}
+
+	for _, file := range []string{"extension.toml", filepath.Join("scripts", "build.sh")} {
+		exists, err := fs.Exists(filepath.Join(buildpackDir, file))
+		if err != nil {
+			return Detection{}, fmt.Errorf("failed to detect packager: %w", err)
+		}
+
+		if exists {
+			return Detection{
+				Packager: "jam",
+				Reason:   fmt.Sprintf("found %s", filepath.ToSlash(file)),
+			}, nil
+		}
+	}
+
+	makefile, err := os.ReadFile(filepath.Join(buildpackDir, "Makefile"))
+	if err != nil && !os.IsNotExist(err) {
+		return Detection{}, fmt.Errorf("failed to detect packager: %w", err)
+	}
+
+	tools := makefileTools(string(makefile))
+	for _, tool := range []string{"libpak-tools", "create-package", "jam"} {
+		if tools[tool] {
+			packager := tool
+			if tool == "create-package" {
+				packager = "libpak"
+			}
+
+			return Detection{
+				Packager: packager,
+				Reason:   fmt.Sprintf("Makefile invokes %s", tool),
+			}, nil
+		}
+	}
+
+	return Detection{}, fmt.Errorf("failed to detect packager for %s: no go.mod requiring packit or libpak, scripts/build.sh or Makefile found", buildpackDir)
+}
+
+// makefileTools returns the names of the commands a Makefile may invoke: each
+// word of its recipes and variables, without its directory or @version, so that
+// $(GOBIN)/jam and go run .../libpak-tools@latest are found, but jammy is not.
+func makefileTools(makefile string) map[string]bool {
+	tools := map[string]bool{}
+	for _, line := range strings.Split(makefile, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+
+		words := strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || strings.ContainsRune(";&|()=\"'`", r)
+		})
+		for _, word := range words {
+			word, _, _ = strings.Cut(word, "@")
+			tools[filepath.Base(word)] = true
+		}
+	}
+
+	return tools
+}
+
+// goModRequirements returns the modules required directly by the go.mod at
+// the given path. A missing go.mod has no requirements.
+func goModRequirements(path string) (map[string]bool, error) {
+	requirements := map[string]bool{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return requirements, nil
+		}
+
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	var inBlock bool
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, comment, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Indirect requirements are dependencies of dependencies, which do
+		// not say how the buildpack itself is packaged.
+		if words := strings.Fields(comment); len(words) > 0 && strings.TrimSuffix(words[0], ";") == "indirect" {
+			continue
+		}
+
+		switch {
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "require" && len(fields) > 1:
+			requirements[fields[1]] = true
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			requirements[fields[0]] = true
+		}
+	}
+
+	return requirements, scanner.Err()
+}
@@ -0,0 +1,206 @@
+package packagers_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/sclevine/spec"
+)
+
+func testDetecting(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		jam         *fakes.Executable
+		libpak      *fakes.Executable
+		libpakTools *fakes.Executable
+		pack        *fakes.Executable
+
+		buildpackDir string
+		output       *bytes.Buffer
+
+		packager packagers.Detecting
+	)
+
+	it.Before(func() {
+		jam = &fakes.Executable{}
+		libpak = &fakes.Executable{}
+		libpakTools = &fakes.Executable{}
+		pack = &fakes.Executable{}
+
+		buildpackDir = t.TempDir()
+		output = bytes.NewBuffer(nil)
+
+		tempOutput := func(string, string) (string, error) {
+			return "some-output-dir", nil
+		}
+
+		packager = packagers.NewDetecting().
+			WithJam(packagers.NewJam().WithExecutable(jam).WithPack(pack).WithTempOutput(tempOutput)).
+			WithLibpak(packagers.NewLibpak().WithExecutable(libpak).WithPack(pack).WithTempOutput(tempOutput)).
+			WithLibpakTools(packagers.NewLibpakTools().WithExecutable(libpakTools).WithPack(pack).WithTempOutput(tempOutput)).
+			WithOutput(output)
+	})
+
+	writeFile := func(name, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(buildpackDir, name)), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(buildpackDir, name), []byte(content), 0600)).To(Succeed())
+	}
+
+	context("Detect", func() {
+		it("chooses libpak-tools for libpak v2 buildpacks", func() {
+			writeFile("go.mod", `module github.com/some-org/some-buildpack
+
+go 1.22
+
+require (
+	github.com/paketo-buildpacks/libpak/v2 v2.1.0
+	github.com/paketo-buildpacks/packit/v2 v2.25.0 // indirect
+)
+`)
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "libpak-tools",
+				Reason:   "go.mod requires github.com/paketo-buildpacks/libpak/v2",
+			}))
+		})
+
+		it("chooses libpak for libpak v1 buildpacks", func() {
+			writeFile("go.mod", "module github.com/some-org/some-buildpack\n\nrequire github.com/paketo-buildpacks/libpak v1.70.0\n")
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "libpak",
+				Reason:   "go.mod requires github.com/paketo-buildpacks/libpak",
+			}))
+		})
+
+		it("chooses jam for packit buildpacks", func() {
+			writeFile("go.mod", "module github.com/some-org/some-buildpack\n\nrequire (\n\tgithub.com/paketo-buildpacks/packit/v2 v2.25.0\n)\n")
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "jam",
+				Reason:   "go.mod requires github.com/paketo-buildpacks/packit/v2",
+			}))
+		})
+
+		it("chooses jam when there is a scripts/build.sh", func() {
+			writeFile("scripts/build.sh", "#!/usr/bin/env bash")
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "jam",
+				Reason:   "found scripts/build.sh",
+			}))
+		})
+
+		it("chooses the tool the Makefile invokes", func() {
+			writeFile("Makefile", "package:\n\tcreate-package --destination ./out\n")
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "libpak",
+				Reason:   "Makefile invokes create-package",
+			}))
+		})
+
+		it("finds tools invoked through a path or go run", func() {
+			writeFile("Makefile", "JAM ?= $(GOBIN)/jam\n\npackage:\n\tgo run github.com/paketo-buildpacks/libpak-tools@latest package\n")
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "libpak-tools",
+				Reason:   "Makefile invokes libpak-tools",
+			}))
+		})
+
+		it("does not mistake words containing a tool name for the tool", func() {
+			writeFile("Makefile", "# builds on jammy\nSTACK = io.buildpacks.stacks.jammy\n\npackage:\n\t./scripts/jamfile.sh\n")
+
+			_, err := packager.Detect(buildpackDir)
+			Expect(err).To(MatchError(ContainSubstring("failed to detect packager for " + buildpackDir)))
+		})
+
+		it("ignores indirect requirements", func() {
+			writeFile("go.mod", `module github.com/some-org/some-buildpack
+
+require (
+	github.com/paketo-buildpacks/libpak v1.70.0 // indirect
+	github.com/paketo-buildpacks/packit/v2 v2.25.0
+)
+`)
+
+			Expect(packager.Detect(buildpackDir)).To(Equal(packagers.Detection{
+				Packager: "jam",
+				Reason:   "go.mod requires github.com/paketo-buildpacks/packit/v2",
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when nothing identifies the packager", func() {
+				it("returns an error", func() {
+					_, err := packager.Detect(buildpackDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to detect packager for " + buildpackDir)))
+				})
+			})
+		})
+	})
+
+	context("Execute", func() {
+		it("dispatches to the detected packager and reports its choice", func() {
+			writeFile("go.mod", "module github.com/some-org/some-buildpack\n\nrequire github.com/paketo-buildpacks/libpak/v2 v2.1.0\n")
+
+			err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(libpakTools.ExecuteCall.CallCount).To(Equal(1))
+			Expect(libpak.ExecuteCall.CallCount).To(Equal(0))
+			Expect(jam.ExecuteCall.CallCount).To(Equal(0))
+			Expect(pack.ExecuteCall.CallCount).To(Equal(1))
+
+			Expect(output.String()).To(ContainSubstring("Packaging %s with libpak-tools: go.mod requires github.com/paketo-buildpacks/libpak/v2", buildpackDir))
+		})
+
+		it("dispatches packit buildpacks to jam", func() {
+			writeFile("scripts/build.sh", "")
+
+			err := packager.Execute(buildpackDir, "some-output", "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jam.ExecuteCall.CallCount).To(Equal(1))
+			Expect(jam.ExecuteCall.Receives.Execution.Args).To(ContainElement("--offline"))
+		})
+
+		it("packages the detected buildpack for the given targets", func() {
+			writeFile("scripts/build.sh", "")
+
+			err := packager.WithTargets("linux/arm64").Execute(buildpackDir, "some-output", "some-version", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pack.ExecuteCall.Receives.Execution.Args).To(ContainElements("--target", "linux/arm64"))
+		})
+
+		context("failure cases", func() {
+			context("when the packager cannot be detected", func() {
+				it("returns an error", func() {
+					err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+					Expect(err).To(MatchError(ContainSubstring("failed to detect packager")))
+				})
+			})
+
+			context("when the detected packager fails", func() {
+				it.Before(func() {
+					writeFile("go.mod", "require github.com/paketo-buildpacks/libpak v1.70.0\n")
+					libpak.ExecuteCall.Returns.Error = errors.New("some libpak error")
+				})
+
+				it("returns an error", func() {
+					err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+					Expect(err).To(MatchError("some libpak error"))
+				})
+			})
+		})
+	})
+}
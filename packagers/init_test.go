@@ -10,7 +10,11 @@ import (
 func TestUnitPackagers(t *testing.T) {
 	suite := spec.New("packagers", spec.Report(report.Terminal{}))
 	suite("Libpak", testLibpak)
+	suite("Native", testNative)
 	suite("LibpakTools", testLibpakTools)
+	suite("Detecting", testDetecting)
 	suite("Jam", testJam)
+	suite("OfflineDependencyVerifier", testOfflineDependencyVerifier)
+	suite("Targets", testTargets)
 	suite.Run(t)
 }
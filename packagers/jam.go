@@ -1,11 +1,13 @@
 package packagers
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"runtime"
 
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 )
@@ -23,13 +25,19 @@ type Jam struct {
 	executable Executable
 	pack       Executable
 	tempOutput func(dir string, pattern string) (string, error)
+	stdout     io.Writer
+	stderr     io.Writer
+	targets    []string
+	ctx        context.Context
 }
 
 func NewJam() Jam {
 	return Jam{
-		executable: pexec.NewExecutable("jam"),
-		pack:       pexec.NewExecutable("pack"),
+		executable: command.New("jam"),
+		pack:       command.New("pack"),
 		tempOutput: os.MkdirTemp,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
 	}
 }
 
@@ -48,10 +56,58 @@ func (j Jam) WithTempOutput(tempOutput func(string, string) (string, error)) Jam
 	return j
 }
 
+// WithOutput sets where the output of the packaging tool and of `pack` is
+// written. It defaults to os.Stdout and os.Stderr, and is also captured in the
+// Logs of the Result.
+func (j Jam) WithOutput(output io.Writer) Jam {
+	j.stdout = output
+	j.stderr = output
+	return j
+}
+
+// WithTargets sets the os/arch[/variant] targets to package the buildpack for.
+// It defaults to linux and the architecture of the host. With several
+// targets, the output is a multi-platform package. When packaging offline,
+// the dependencies that do not match a target are left out of its package.
+func (j Jam) WithTargets(targets ...string) Jam {
+	j.targets = targets
+	return j
+}
+
+// WithContext stops packaging, killing the running tool, when the context is
+// done. The error then includes the output of the tools so far.
+func (j Jam) WithContext(ctx context.Context) Jam {
+	j.ctx = ctx
+	return j
+}
+
 func (j Jam) Execute(buildpackDir, output, version string, offline bool) error {
+	var logs logBuffer
+	_, err := j.run(buildpackDir, output, version, offline, &logs)
+	return logs.timeout(err)
+}
+
+// Package is like Execute, but also describes the packaged buildpack.
+func (j Jam) Package(buildpackDir, output, version string, offline bool) (Result, error) {
+	var logs logBuffer
+	targets, err := j.run(buildpackDir, output, version, offline, &logs)
+	if err != nil {
+		err = logs.timeout(err)
+		return Result{Path: output, Logs: logs.String()}, err
+	}
+
+	result, err := newResult(output, logs.String())
+	result.Targets = targets
+
+	return result, err
+}
+
+func (j Jam) run(buildpackDir, output, version string, offline bool, logs *logBuffer) ([]TargetPackage, error) {
+	stdout, stderr := logs.tee(j.stdout, j.stderr)
+
 	jamOutput, err := j.tempOutput("", "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := os.RemoveAll(jamOutput); err != nil {
@@ -83,18 +139,18 @@ func (j Jam) Execute(buildpackDir, output, version string, offline bool) error {
 		args = append(args, "--offline")
 	}
 
-	err = j.executable.Execute(pexec.Execution{
+	err = command.WithContext(j.ctx, "jam", j.executable).Execute(pexec.Execution{
 		Args:   args,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
+		Stdout: stdout,
+		Stderr: stderr,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tmpDir, _ := os.MkdirTemp("", "build")
 	if _, err := os.Stat(buildpackTarballPath); err == nil {
-		doUnzip := pexec.NewExecutable("tar")
+		doUnzip := command.WithContext(j.ctx, "tar", command.New("tar"))
 		args = []string{
 			"-xvf",
 			buildpackTarballPath,
@@ -102,32 +158,28 @@ func (j Jam) Execute(buildpackDir, output, version string, offline bool) error {
 		err = doUnzip.Execute(pexec.Execution{
 			Dir:    tmpDir,
 			Args:   args,
-			Stdout: os.Stdout,
-			Stderr: os.Stderr,
+			Stdout: stdout,
+			Stderr: stderr,
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 	}
 
-	args = []string{
-		buildpackType, "package",
-		output,
-		"--format", "file",
-		"--target", fmt.Sprintf("linux/%s", runtime.GOARCH),
-	}
-
-	err = j.pack.Execute(pexec.Execution{
-		Dir:    tmpDir,
-		Args:   args,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	})
+	targets, err := packStep{
+		pack:    command.WithContext(j.ctx, "pack", j.pack),
+		kind:    buildpackType,
+		dir:     tmpDir,
+		targets: j.targets,
+		filter:  offline && len(j.targets) > 0,
+		stdout:  stdout,
+		stderr:  stderr,
+	}.execute(output)
 
 	if err := os.RemoveAll(tmpDir); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: failed to clean up temp directory: %s\n", err)
 	}
 
-	return err
+	return targets, err
 }
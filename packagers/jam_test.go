@@ -1,16 +1,22 @@
 package packagers_test
 
 import (
+	"bytes"
+	ctx "context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/occam/fakes"
 	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/sclevine/spec"
 )
 
@@ -151,6 +157,137 @@ func testJam(t *testing.T, context spec.G, it spec.S) {
 					Expect(err).To(MatchError("some pack error"))
 				})
 			})
+
+			context("when the context is done", func() {
+				var cancel ctx.CancelFunc
+
+				it.Before(func() {
+					var c ctx.Context
+					c, cancel = ctx.WithTimeout(ctx.Background(), time.Minute)
+					packager = packager.WithContext(c).WithOutput(io.Discard)
+
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stdout, "some partial output")
+						cancel()
+						return errors.New("signal: killed")
+					}
+				})
+
+				it.After(func() {
+					cancel()
+				})
+
+				it("returns an error with the output so far", func() {
+					err := packager.Execute("some-buildpack-dir", "some-output", "some-version", false)
+					Expect(err).To(MatchError(fmt.Sprintf("command `jam pack --buildpack %s --output %s --version some-version` was cancelled: context canceled\n\nOutput:\nsome partial output\n",
+						filepath.Join("some-buildpack-dir", "buildpack.toml"),
+						filepath.Join("some-jam-output", "some-version.tgz"),
+					)))
+					Expect(errors.Is(err, ctx.Canceled)).To(BeTrue())
+					Expect(pack.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the context is already done", func() {
+				it.Before(func() {
+					c, cancel := ctx.WithCancel(ctx.Background())
+					cancel()
+					packager = packager.WithContext(c)
+				})
+
+				it("does not run jam", func() {
+					err := packager.Execute("some-buildpack-dir", "some-output", "some-version", false)
+					Expect(err).To(MatchError(ContainSubstring("command `jam pack")))
+					Expect(errors.Is(err, ctx.Canceled)).To(BeTrue())
+					Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	context("Package", func() {
+		var output string
+
+		it.Before(func() {
+			output = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "some jam output")
+				_, _ = fmt.Fprintln(execution.Stderr, "some jam warning")
+				return nil
+			}
+
+			pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "some pack output")
+				return writeCNB(execution.Args[2], "some-org/some-buildpack", "some-version", map[string]string{
+					"cnb/buildpacks/some-org_some-buildpack/some-version/buildpack.toml":                            "[[metadata.dependencies]]\nid = \"some-dependency\"\nuri = \"file:///dependencies/some-sha/some-dependency.tgz\"\n",
+					"cnb/buildpacks/some-org_some-buildpack/some-version/dependencies/some-sha/some-dependency.tgz": "some-dependency",
+				})
+			}
+		})
+
+		it("describes the packaged buildpack", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			result, err := packager.WithOutput(buffer).Package("some-buildpack-dir", output, "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			digest, err := os.ReadFile(output)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result).To(Equal(packagers.Result{
+				Path:             output,
+				Digest:           fmt.Sprintf("sha256:%x", sha256.Sum256(digest)),
+				BuildpackID:      "some-org/some-buildpack",
+				BuildpackVersion: "some-version",
+				Offline:          true,
+				Logs:             "some jam output\nsome jam warning\nsome pack output\n",
+			}))
+			Expect(buffer.String()).To(Equal(result.Logs))
+		})
+
+		context("when the packaged buildpack.toml keeps the remote dependency uris", func() {
+			it.Before(func() {
+				pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					return writeCNB(execution.Args[2], "some-org/some-buildpack", "some-version", map[string]string{
+						"cnb/buildpacks/some-org_some-buildpack/some-version/buildpack.toml": "[[metadata.dependencies]]\nid = \"some-dependency\"\nuri = \"https://example.com/some-dependency.tgz\"\n",
+					})
+				}
+			})
+
+			it("reports the package as online", func() {
+				result, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Offline).To(BeFalse())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the jam execution returns an error", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some jam failure")
+						return errors.New("some jam error")
+					}
+				})
+
+				it("returns the captured logs with the error", func() {
+					result, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", false)
+					Expect(err).To(MatchError("some jam error"))
+					Expect(result.Logs).To(Equal("some jam failure\n"))
+				})
+			})
+
+			context("when pack does not write the output", func() {
+				it.Before(func() {
+					pack.ExecuteCall.Stub = nil
+				})
+
+				it("returns an error", func() {
+					_, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", false)
+					Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed to read packaged buildpack %s", output))))
+				})
+			})
 		})
 	})
 }
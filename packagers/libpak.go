@@ -1,10 +1,12 @@
 package packagers
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
 
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 )
 
@@ -16,13 +18,19 @@ type Libpak struct {
 	executable Executable
 	pack       Executable
 	tempOutput func(dir string, pattern string) (string, error)
+	stdout     io.Writer
+	stderr     io.Writer
+	targets    []string
+	ctx        context.Context
 }
 
 func NewLibpak() Libpak {
 	return Libpak{
-		executable: pexec.NewExecutable("create-package"),
-		pack:       pexec.NewExecutable("pack"),
+		executable: command.New("create-package"),
+		pack:       command.New("pack"),
 		tempOutput: os.MkdirTemp,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
 	}
 }
 
@@ -41,10 +49,58 @@ func (l Libpak) WithTempOutput(tempOutput func(string, string) (string, error))
 	return l
 }
 
+// WithOutput sets where the output of the packaging tool and of `pack` is
+// written. It defaults to os.Stdout and os.Stderr, and is also captured in the
+// Logs of the Result.
+func (l Libpak) WithOutput(output io.Writer) Libpak {
+	l.stdout = output
+	l.stderr = output
+	return l
+}
+
+// WithTargets sets the os/arch[/variant] targets to package the buildpack for.
+// It defaults to linux and the architecture of the host. With several
+// targets, the output is a multi-platform package. When packaging offline,
+// the dependencies that do not match a target are left out of its package.
+func (l Libpak) WithTargets(targets ...string) Libpak {
+	l.targets = targets
+	return l
+}
+
+// WithContext stops packaging, killing the running tool, when the context is
+// done. The error then includes the output of the tools so far.
+func (l Libpak) WithContext(ctx context.Context) Libpak {
+	l.ctx = ctx
+	return l
+}
+
 func (l Libpak) Execute(buildpackDir, output, version string, cached bool) error {
+	var logs logBuffer
+	_, err := l.run(buildpackDir, output, version, cached, &logs)
+	return logs.timeout(err)
+}
+
+// Package is like Execute, but also describes the packaged buildpack.
+func (l Libpak) Package(buildpackDir, output, version string, cached bool) (Result, error) {
+	var logs logBuffer
+	targets, err := l.run(buildpackDir, output, version, cached, &logs)
+	if err != nil {
+		err = logs.timeout(err)
+		return Result{Path: output, Logs: logs.String()}, err
+	}
+
+	result, err := newResult(output, logs.String())
+	result.Targets = targets
+
+	return result, err
+}
+
+func (l Libpak) run(buildpackDir, output, version string, cached bool, logs *logBuffer) ([]TargetPackage, error) {
+	stdout, stderr := logs.tee(l.stdout, l.stderr)
+
 	libpakOutput, err := l.tempOutput("", "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := os.RemoveAll(libpakOutput); err != nil {
@@ -61,28 +117,25 @@ func (l Libpak) Execute(buildpackDir, output, version string, cached bool) error
 		args = append(args, "--include-dependencies")
 	}
 
-	err = l.executable.Execute(pexec.Execution{
+	err = command.WithContext(l.ctx, "create-package", l.executable).Execute(pexec.Execution{
 		Args:   args,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
+		Stdout: stdout,
+		Stderr: stderr,
 		Dir:    buildpackDir,
 	})
 
 	if err != nil {
-		return err
-	}
-
-	args = []string{
-		"buildpack", "package",
-		output,
-		"--path", libpakOutput,
-		"--format", "file",
-		"--target", fmt.Sprintf("linux/%s", runtime.GOARCH),
+		return nil, err
 	}
 
-	return l.pack.Execute(pexec.Execution{
-		Args:   args,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	})
+	return packStep{
+		pack:    command.WithContext(l.ctx, "pack", l.pack),
+		kind:    "buildpack",
+		dir:     libpakOutput,
+		path:    true,
+		targets: l.targets,
+		filter:  cached && len(l.targets) > 0,
+		stdout:  stdout,
+		stderr:  stderr,
+	}.execute(output)
 }
@@ -1,14 +1,22 @@
 package packagers_test
 
 import (
+	"bytes"
+	ctx "context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/occam/fakes"
 	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/sclevine/spec"
 )
 
@@ -111,6 +119,103 @@ func testLibpak(t *testing.T, context spec.G, it spec.S) {
 					Expect(err).To(MatchError("some pack error"))
 				})
 			})
+
+			context("when pack does not finish before the deadline", func() {
+				var cancel ctx.CancelFunc
+
+				it.Before(func() {
+					var c ctx.Context
+					c, cancel = ctx.WithTimeout(ctx.Background(), 10*time.Millisecond)
+					packager = packager.WithContext(c).WithOutput(io.Discard)
+
+					pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some partial output")
+						<-c.Done()
+						return errors.New("signal: killed")
+					}
+				})
+
+				it.After(func() {
+					cancel()
+				})
+
+				it("returns a timeout error with the output so far", func() {
+					err := packager.Execute("some-buildpack-dir", "some-output", "some-version", true)
+					Expect(err).To(MatchError(ContainSubstring("command `pack buildpack package some-output")))
+					Expect(err).To(MatchError(ContainSubstring("timed out: context deadline exceeded\n\nOutput:\nsome partial output\n")))
+					Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+				})
+			})
+		})
+	})
+
+	context("Package", func() {
+		var output string
+
+		it.Before(func() {
+			output = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "some libpak output")
+				_, _ = fmt.Fprintln(execution.Stderr, "some libpak warning")
+				return nil
+			}
+
+			pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "some pack output")
+				return writeCNB(execution.Args[2], "some-org/some-buildpack", "some-version", map[string]string{
+					"cnb/buildpacks/some-org_some-buildpack/some-version/buildpack.toml":                            "[[metadata.dependencies]]\nid = \"some-dependency\"\nuri = \"https://example.com/some-dependency.tgz\"\n",
+					"cnb/buildpacks/some-org_some-buildpack/some-version/dependencies/some-sha/some-dependency.tgz": "some-dependency",
+				})
+			}
+		})
+
+		it("describes the packaged buildpack", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			result, err := packager.WithOutput(buffer).Package("some-buildpack-dir", output, "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			digest, err := os.ReadFile(output)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result).To(Equal(packagers.Result{
+				Path:             output,
+				Digest:           fmt.Sprintf("sha256:%x", sha256.Sum256(digest)),
+				BuildpackID:      "some-org/some-buildpack",
+				BuildpackVersion: "some-version",
+				Offline:          true,
+				Logs:             "some libpak output\nsome libpak warning\nsome pack output\n",
+			}))
+			Expect(buffer.String()).To(Equal(result.Logs))
+		})
+
+		context("failure cases", func() {
+			context("when the libpak execution returns an error", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some libpak failure")
+						return errors.New("some libpak error")
+					}
+				})
+
+				it("returns the captured logs with the error", func() {
+					result, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", false)
+					Expect(err).To(MatchError("some libpak error"))
+					Expect(result.Logs).To(Equal("some libpak failure\n"))
+				})
+			})
+
+			context("when pack does not write the output", func() {
+				it.Before(func() {
+					pack.ExecuteCall.Stub = nil
+				})
+
+				it("returns an error", func() {
+					_, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", false)
+					Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed to read packaged buildpack %s", output))))
+				})
+			})
 		})
 	})
 }
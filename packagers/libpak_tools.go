@@ -1,10 +1,12 @@
 package packagers
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
 
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 )
 
@@ -14,13 +16,19 @@ type LibpakTools struct {
 	executable Executable
 	pack       Executable
 	tempOutput func(dir string, pattern string) (string, error)
+	stdout     io.Writer
+	stderr     io.Writer
+	targets    []string
+	ctx        context.Context
 }
 
 func NewLibpakTools() LibpakTools {
 	return LibpakTools{
-		executable: pexec.NewExecutable("libpak-tools"),
-		pack:       pexec.NewExecutable("pack"),
+		executable: command.New("libpak-tools"),
+		pack:       command.New("pack"),
 		tempOutput: os.MkdirTemp,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
 	}
 }
 
@@ -39,10 +47,58 @@ func (l LibpakTools) WithTempOutput(tempOutput func(string, string) (string, err
 	return l
 }
 
+// WithOutput sets where the output of the packaging tool and of `pack` is
+// written. It defaults to os.Stdout and os.Stderr, and is also captured in the
+// Logs of the Result.
+func (l LibpakTools) WithOutput(output io.Writer) LibpakTools {
+	l.stdout = output
+	l.stderr = output
+	return l
+}
+
+// WithTargets sets the os/arch[/variant] targets to package the buildpack for.
+// It defaults to linux and the architecture of the host. With several
+// targets, the output is a multi-platform package. When packaging offline,
+// the dependencies that do not match a target are left out of its package.
+func (l LibpakTools) WithTargets(targets ...string) LibpakTools {
+	l.targets = targets
+	return l
+}
+
+// WithContext stops packaging, killing the running tool, when the context is
+// done. The error then includes the output of the tools so far.
+func (l LibpakTools) WithContext(ctx context.Context) LibpakTools {
+	l.ctx = ctx
+	return l
+}
+
 func (l LibpakTools) Execute(buildpackDir, output, version string, cached bool) error {
+	var logs logBuffer
+	_, err := l.run(buildpackDir, output, version, cached, &logs)
+	return logs.timeout(err)
+}
+
+// Package is like Execute, but also describes the packaged buildpack.
+func (l LibpakTools) Package(buildpackDir, output, version string, cached bool) (Result, error) {
+	var logs logBuffer
+	targets, err := l.run(buildpackDir, output, version, cached, &logs)
+	if err != nil {
+		err = logs.timeout(err)
+		return Result{Path: output, Logs: logs.String()}, err
+	}
+
+	result, err := newResult(output, logs.String())
+	result.Targets = targets
+
+	return result, err
+}
+
+func (l LibpakTools) run(buildpackDir, output, version string, cached bool, logs *logBuffer) ([]TargetPackage, error) {
+	stdout, stderr := logs.tee(l.stdout, l.stderr)
+
 	libpakToolsOutput, err := l.tempOutput("", "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err := os.RemoveAll(libpakToolsOutput); err != nil {
@@ -61,28 +117,25 @@ func (l LibpakTools) Execute(buildpackDir, output, version string, cached bool)
 		args = append(args, "--include-dependencies")
 	}
 
-	err = l.executable.Execute(pexec.Execution{
+	err = command.WithContext(l.ctx, "libpak-tools", l.executable).Execute(pexec.Execution{
 		Args:   args,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
+		Stdout: stdout,
+		Stderr: stderr,
 		Dir:    buildpackDir,
 	})
 
 	if err != nil {
-		return err
-	}
-
-	args = []string{
-		"buildpack", "package",
-		output,
-		"--path", libpakToolsOutput,
-		"--format", "file",
-		"--target", fmt.Sprintf("linux/%s", runtime.GOARCH),
+		return nil, err
 	}
 
-	return l.pack.Execute(pexec.Execution{
-		Args:   args,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	})
+	return packStep{
+		pack:    command.WithContext(l.ctx, "pack", l.pack),
+		kind:    "buildpack",
+		dir:     libpakToolsOutput,
+		path:    true,
+		targets: l.targets,
+		filter:  cached && len(l.targets) > 0,
+		stdout:  stdout,
+		stderr:  stderr,
+	}.execute(output)
 }
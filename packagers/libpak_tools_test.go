@@ -1,14 +1,20 @@
 package packagers_test
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/occam/fakes"
 	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/sclevine/spec"
 )
 
@@ -117,4 +123,74 @@ func testLibpakTools(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 	})
+
+	context("Package", func() {
+		var output string
+
+		it.Before(func() {
+			output = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "some libpak-tools output")
+				_, _ = fmt.Fprintln(execution.Stderr, "some libpak-tools warning")
+				return nil
+			}
+
+			pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stdout, "some pack output")
+				return writeCNB(execution.Args[2], "some-org/some-buildpack", "some-version", map[string]string{
+					"cnb/buildpacks/some-org_some-buildpack/some-version/buildpack.toml":                            "[[metadata.dependencies]]\nid = \"some-dependency\"\nuri = \"https://example.com/some-dependency.tgz\"\n",
+					"cnb/buildpacks/some-org_some-buildpack/some-version/dependencies/some-sha/some-dependency.tgz": "some-dependency",
+				})
+			}
+		})
+
+		it("describes the packaged buildpack", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			result, err := packager.WithOutput(buffer).Package("some-buildpack-dir", output, "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			digest, err := os.ReadFile(output)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result).To(Equal(packagers.Result{
+				Path:             output,
+				Digest:           fmt.Sprintf("sha256:%x", sha256.Sum256(digest)),
+				BuildpackID:      "some-org/some-buildpack",
+				BuildpackVersion: "some-version",
+				Offline:          true,
+				Logs:             "some libpak-tools output\nsome libpak-tools warning\nsome pack output\n",
+			}))
+			Expect(buffer.String()).To(Equal(result.Logs))
+		})
+
+		context("failure cases", func() {
+			context("when the libpak-tools execution returns an error", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						_, _ = fmt.Fprintln(execution.Stderr, "some libpak-tools failure")
+						return errors.New("some libpak-tools error")
+					}
+				})
+
+				it("returns the captured logs with the error", func() {
+					result, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", false)
+					Expect(err).To(MatchError("some libpak-tools error"))
+					Expect(result.Logs).To(Equal("some libpak-tools failure\n"))
+				})
+			})
+
+			context("when pack does not write the output", func() {
+				it.Before(func() {
+					pack.ExecuteCall.Stub = nil
+				})
+
+				it("returns an error", func() {
+					_, err := packager.WithOutput(io.Discard).Package("some-buildpack-dir", output, "some-version", false)
+					Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed to read packaged buildpack %s", output))))
+				})
+			})
+		})
+	})
 }
@@ -0,0 +1,437 @@
+package packagers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/paketo-buildpacks/occam/internal/command"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// Native is a packager that builds packit buildpacks' source code without
+// the jam executable. It reads the buildpack.toml, runs its pre-package
+// script, collects the include-files and, when packaging offline, vendors the
+// dependencies into the buildpack the same way `jam pack --offline` does.
+// Only `pack buildpack package` is executed as an external tool.
+//
+// Native implements the freezer.Packager interface, and can therefore be
+// passed as an argument to occam.BuildpackStore.WithPackager().
+type Native struct {
+	shell      Executable
+	pack       Executable
+	client     *http.Client
+	tempOutput func(dir string, pattern string) (string, error)
+	stdout     io.Writer
+	stderr     io.Writer
+	targets    []string
+	ctx        context.Context
+}
+
+func NewNative() Native {
+	return Native{
+		shell:      command.New("bash"),
+		pack:       command.New("pack"),
+		client:     http.DefaultClient,
+		tempOutput: os.MkdirTemp,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+	}
+}
+
+// WithShell sets the shell used to run the pre-package script. It is invoked
+// as `<shell> -c <pre-package>` from the buildpack directory.
+func (n Native) WithShell(shell Executable) Native {
+	n.shell = shell
+	return n
+}
+
+func (n Native) WithPack(pack Executable) Native {
+	n.pack = pack
+	return n
+}
+
+// WithHTTPClient sets the client used to download dependencies when
+// packaging offline.
+func (n Native) WithHTTPClient(client *http.Client) Native {
+	n.client = client
+	return n
+}
+
+func (n Native) WithTempOutput(tempOutput func(string, string) (string, error)) Native {
+	n.tempOutput = tempOutput
+	return n
+}
+
+// WithOutput sets where the output of the pre-package script and of `pack`
+// is written. It defaults to os.Stdout and os.Stderr, and is also captured in
+// the Logs of the Result.
+func (n Native) WithOutput(output io.Writer) Native {
+	n.stdout = output
+	n.stderr = output
+	return n
+}
+
+// WithTargets sets the os/arch[/variant] targets to package the buildpack for.
+// It defaults to linux and the architecture of the host. With several
+// targets, the output is a multi-platform package. When packaging offline,
+// the dependencies that do not match a target are left out of its package.
+func (n Native) WithTargets(targets ...string) Native {
+	n.targets = targets
+	return n
+}
+
+// WithContext stops packaging, killing the running tool, when the context is
+// done. The error then includes the output of the tools so far.
+func (n Native) WithContext(ctx context.Context) Native {
+	n.ctx = ctx
+	return n
+}
+
+func (n Native) Execute(buildpackDir, output, version string, offline bool) error {
+	var logs logBuffer
+	_, err := n.run(buildpackDir, output, version, offline, &logs)
+	return logs.timeout(err)
+}
+
+// Package is like Execute, but also describes the packaged buildpack.
+func (n Native) Package(buildpackDir, output, version string, offline bool) (Result, error) {
+	var logs logBuffer
+	targets, err := n.run(buildpackDir, output, version, offline, &logs)
+	if err != nil {
+		err = logs.timeout(err)
+		return Result{Path: output, Logs: logs.String()}, err
+	}
+
+	result, err := newResult(output, logs.String())
+	result.Targets = targets
+
+	return result, err
+}
+
+// Archive writes the buildpack as a gzipped tarball, like `jam pack`, without
+// packaging it with `pack`.
+func (n Native) Archive(buildpackDir, output, version string, offline bool) error {
+	stageDir, err := n.tempOutput("", "")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(stageDir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up native packager output: %s\n", err)
+		}
+	}()
+
+	var logs logBuffer
+	err = n.stage(buildpackDir, stageDir, version, offline, &logs)
+	if err != nil {
+		return err
+	}
+
+	return writeArchive(filepath.Join(stageDir, "buildpack"), output, true)
+}
+
+func (n Native) run(buildpackDir, output, version string, offline bool, logs *logBuffer) ([]TargetPackage, error) {
+	stageDir, err := n.tempOutput("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(stageDir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up native packager output: %s\n", err)
+		}
+	}()
+
+	err = n.stage(buildpackDir, stageDir, version, offline, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr := logs.tee(n.stdout, n.stderr)
+
+	return packStep{
+		pack:    command.WithContext(n.ctx, "pack", n.pack),
+		kind:    "buildpack",
+		dir:     filepath.Join(stageDir, "buildpack"),
+		targets: n.targets,
+		filter:  offline && len(n.targets) > 0,
+		stdout:  stdout,
+		stderr:  stderr,
+	}.execute(output)
+}
+
+// stage assembles the contents of the packaged buildpack in
+// <stageDir>/buildpack, using <stageDir>/source as a working copy of the
+// buildpack source.
+func (n Native) stage(buildpackDir, stageDir, version string, offline bool, logs *logBuffer) error {
+	if exists, err := fs.Exists(filepath.Join(buildpackDir, "extension.toml")); err == nil && exists {
+		return fmt.Errorf("failed to package %s: extensions are not supported by the native packager", buildpackDir)
+	}
+
+	config, err := cargo.NewBuildpackParser().Parse(filepath.Join(buildpackDir, "buildpack.toml"))
+	if err != nil {
+		return fmt.Errorf("failed to parse buildpack.toml: %w", err)
+	}
+
+	sourceDir := filepath.Join(stageDir, "source")
+	err = fs.Copy(buildpackDir, sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to copy buildpack source: %w", err)
+	}
+
+	if config.Metadata.PrePackage != "" {
+		stdout, stderr := logs.tee(n.stdout, n.stderr)
+
+		err = command.WithContext(n.ctx, "bash", n.shell).Execute(pexec.Execution{
+			Args:   []string{"-c", config.Metadata.PrePackage},
+			Dir:    sourceDir,
+			Stdout: stdout,
+			Stderr: stderr,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to run pre-package script %q: %w", config.Metadata.PrePackage, err)
+		}
+	}
+
+	targetDir := filepath.Join(stageDir, "buildpack")
+	err = os.MkdirAll(targetDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range config.Metadata.IncludeFiles {
+		if file == "buildpack.toml" {
+			continue
+		}
+
+		destination := filepath.Join(targetDir, file)
+		err = os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		err = fs.Copy(filepath.Join(sourceDir, file), destination)
+		if err != nil {
+			return fmt.Errorf("failed to include file %s: %w", file, err)
+		}
+	}
+
+	config.Buildpack.Version = version
+
+	var platforms []v1.Platform
+	for _, target := range n.targets {
+		platform, err := parseTarget(target)
+		if err != nil {
+			return err
+		}
+
+		platforms = append(platforms, platform)
+	}
+
+	if offline {
+		for i, dependency := range config.Metadata.Dependencies {
+			// Dependencies for none of the targets are left out of every
+			// package, so there is no need to download them.
+			if len(platforms) > 0 && !slices.ContainsFunc(platforms, func(platform v1.Platform) bool {
+				return dependencyMatches(dependency, platform)
+			}) {
+				continue
+			}
+
+			uri, err := n.vendor(dependency, targetDir)
+			if err != nil {
+				return err
+			}
+
+			config.Metadata.Dependencies[i].URI = uri
+			config.Metadata.IncludeFiles = append(config.Metadata.IncludeFiles, uri[len("file:///"):])
+		}
+	}
+
+	file, err := os.Create(filepath.Join(targetDir, "buildpack.toml"))
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack.toml: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close buildpack.toml: %s\n", err)
+		}
+	}()
+
+	err = cargo.EncodeConfig(file, config)
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack.toml: %w", err)
+	}
+
+	return nil
+}
+
+// vendor downloads the dependency into dependencies/<sha256>/<name> in the
+// buildpack and returns the file:// URI it can be found at.
+func (n Native) vendor(dependency cargo.ConfigMetadataDependency, buildpackDir string) (string, error) {
+	checksum := dependencyChecksum(dependency)
+
+	u, err := url.Parse(dependency.URI)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse uri of %s: %w", describeDependency(dependency), err)
+	}
+
+	name := path.Join("dependencies", checksum.Hash(), path.Base(u.Path))
+	destination := filepath.Join(buildpackDir, filepath.FromSlash(name))
+
+	// Dependencies shared between stacks are only downloaded once.
+	if _, err := os.Stat(destination); err == nil {
+		return fmt.Sprintf("file:///%s", name), nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := n.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, dependency.URI, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", describeDependency(dependency), err)
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", describeDependency(dependency), err)
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close response body: %s\n", err)
+		}
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: unexpected status %s", describeDependency(dependency), response.Status)
+	}
+
+	file, err := os.Create(destination)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(file, response.Body)
+	if err != nil {
+		_ = file.Close()
+		return "", fmt.Errorf("failed to download %s: %w", describeDependency(dependency), err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	actual, err := fileChecksum(buildpackDir, name, checksum.Algorithm())
+	if err != nil {
+		return "", err
+	}
+
+	if actual != checksum.Hash() {
+		return "", fmt.Errorf("failed to download %s: checksum mismatch: expected %s, got %s", describeDependency(dependency), checksum.Hash(), actual)
+	}
+
+	return fmt.Sprintf("file:///%s", name), nil
+}
+
+// writeArchive writes the contents of the directory as a tarball, gzipped when
+// compress is set.
+func writeArchive(dir, output string, compress bool) error {
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", output, err)
+		}
+	}()
+
+	var writer io.WriteCloser = nopWriteCloser{file}
+	if compress {
+		writer = gzip.NewWriter(file)
+	}
+	tw := tar.NewWriter(writer)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		err = tw.WriteHeader(header)
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := content.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+			}
+		}()
+
+		_, err = io.Copy(tw, content)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write tarball: %w", err)
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write tarball: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write tarball: %w", err)
+	}
+
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
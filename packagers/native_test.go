@@ -0,0 +1,288 @@
+package packagers_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+	"github.com/sclevine/spec"
+)
+
+func testNative(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		shell *fakes.Executable
+		pack  *fakes.Executable
+
+		server       *httptest.Server
+		buildpackDir string
+		checksum     string
+
+		staged         []string
+		stagedConfig   cargo.Config
+		stagedChecksum string
+
+		packager packagers.Native
+	)
+
+	it.Before(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/some-dependency.tgz" {
+				http.NotFound(w, req)
+				return
+			}
+
+			_, _ = fmt.Fprint(w, "some-dependency-content")
+		}))
+		checksum = fmt.Sprintf("%x", sha256.Sum256([]byte("some-dependency-content")))
+
+		buildpackDir = t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(buildpackDir, "scripts"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(buildpackDir, "README.md"), []byte("not included"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(buildpackDir, "buildpack.toml"), []byte(fmt.Sprintf(`
+api = "0.7"
+
+[buildpack]
+  id = "some-org/some-buildpack"
+  version = "{{ .version }}"
+
+[metadata]
+  include-files = ["bin/build", "bin/detect", "buildpack.toml"]
+  pre-package = "./scripts/build.sh"
+
+  [[metadata.dependencies]]
+    id = "some-dependency"
+    version = "1.2.3"
+    checksum = "sha256:%s"
+    uri = "%s/some-dependency.tgz"
+    stacks = ["some-stack"]
+
+  [[metadata.dependencies]]
+    id = "some-dependency"
+    version = "1.2.3"
+    checksum = "sha256:%s"
+    uri = "%s/some-dependency.tgz"
+    stacks = ["other-stack"]
+`, checksum, server.URL, checksum, server.URL)), 0600)).To(Succeed())
+
+		shell = &fakes.Executable{}
+		shell.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			_, _ = fmt.Fprintln(execution.Stdout, "building")
+
+			err := os.MkdirAll(filepath.Join(execution.Dir, "bin"), os.ModePerm)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range []string{"build", "detect"} {
+				err = os.WriteFile(filepath.Join(execution.Dir, "bin", name), []byte(name), 0755)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		staged = nil
+		pack = &fakes.Executable{}
+		pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			err := filepath.Walk(execution.Dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				rel, err := filepath.Rel(execution.Dir, path)
+				if err != nil {
+					return err
+				}
+
+				staged = append(staged, filepath.ToSlash(rel))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			stagedConfig, err = cargo.NewBuildpackParser().Parse(filepath.Join(execution.Dir, "buildpack.toml"))
+			if err != nil {
+				return err
+			}
+
+			if len(stagedConfig.Metadata.Dependencies) > 0 && strings.HasPrefix(stagedConfig.Metadata.Dependencies[0].URI, "file:///") {
+				content, err := os.ReadFile(filepath.Join(execution.Dir, strings.TrimPrefix(stagedConfig.Metadata.Dependencies[0].URI, "file:///")))
+				if err != nil {
+					return err
+				}
+				stagedChecksum = fmt.Sprintf("%x", sha256.Sum256(content))
+			}
+
+			return nil
+		}
+
+		packager = packagers.NewNative().WithShell(shell).WithPack(pack).WithOutput(io.Discard)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("Execute", func() {
+		it("stages the included files and packages them with pack", func() {
+			err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(shell.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-c", "./scripts/build.sh"}))
+
+			Expect(staged).To(ConsistOf("bin/build", "bin/detect", "buildpack.toml"))
+			Expect(stagedConfig.Buildpack.Version).To(Equal("some-version"))
+			Expect(stagedConfig.Metadata.Dependencies[0].URI).To(Equal(server.URL + "/some-dependency.tgz"))
+
+			Expect(pack.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+				"buildpack", "package",
+				"some-output",
+				"--format", "file",
+				"--target", fmt.Sprintf("linux/%s", runtime.GOARCH),
+			}))
+
+			_, err = os.Stat(filepath.Join(buildpackDir, "bin"))
+			Expect(err).To(MatchError(os.ErrNotExist), "the pre-package script must not modify the source")
+		})
+
+		context("when packaging with offline dependencies", func() {
+			it("vendors the dependencies and rewrites their URIs", func() {
+				err := packager.Execute(buildpackDir, "some-output", "some-version", true)
+				Expect(err).NotTo(HaveOccurred())
+
+				dependency := fmt.Sprintf("dependencies/%s/some-dependency.tgz", checksum)
+				Expect(staged).To(ConsistOf("bin/build", "bin/detect", "buildpack.toml", dependency))
+
+				Expect(stagedConfig.Metadata.Dependencies).To(HaveLen(2))
+				for _, d := range stagedConfig.Metadata.Dependencies {
+					Expect(d.URI).To(Equal("file:///" + dependency))
+				}
+				Expect(stagedConfig.Metadata.IncludeFiles).To(ContainElement(dependency))
+				Expect(stagedChecksum).To(Equal(checksum))
+			})
+		})
+	})
+
+	context("Archive", func() {
+		it("writes the buildpack tarball", func() {
+			output := filepath.Join(t.TempDir(), "buildpack.tgz")
+
+			err := packager.Archive(buildpackDir, output, "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pack.ExecuteCall.CallCount).To(Equal(0))
+
+			file, err := os.Open(output)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			dir := t.TempDir()
+			Expect(vacation.NewArchive(file).Decompress(dir)).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(dir, "bin", "build"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("build"))
+
+			config, err := cargo.NewBuildpackParser().Parse(filepath.Join(dir, "buildpack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(packagers.NewOfflineDependencyVerifier().Execute(output, config)).To(Succeed())
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the buildpack.toml cannot be parsed", func() {
+			it("returns an error", func() {
+				err := packager.Execute(t.TempDir(), "some-output", "some-version", false)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse buildpack.toml")))
+			})
+		})
+
+		context("when given an extension", func() {
+			it("returns an error", func() {
+				Expect(os.WriteFile(filepath.Join(buildpackDir, "extension.toml"), nil, 0600)).To(Succeed())
+
+				err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+				Expect(err).To(MatchError(ContainSubstring("extensions are not supported by the native packager")))
+			})
+		})
+
+		context("when the pre-package script fails", func() {
+			it.Before(func() {
+				shell.ExecuteCall.Stub = nil
+				shell.ExecuteCall.Returns.Error = errors.New("exit status 1")
+			})
+
+			it("returns an error", func() {
+				err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+				Expect(err).To(MatchError(`failed to run pre-package script "./scripts/build.sh": exit status 1`))
+			})
+		})
+
+		context("when an include file is missing", func() {
+			it.Before(func() {
+				shell.ExecuteCall.Stub = nil
+			})
+
+			it("returns an error", func() {
+				err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+				Expect(err).To(MatchError(ContainSubstring("failed to include file bin/build")))
+			})
+		})
+
+		context("when a dependency cannot be downloaded", func() {
+			it.Before(func() {
+				server.Close()
+			})
+
+			it("returns an error", func() {
+				err := packager.Execute(buildpackDir, "some-output", "some-version", true)
+				Expect(err).To(MatchError(ContainSubstring("failed to download some-dependency@1.2.3 (stacks: some-stack)")))
+			})
+		})
+
+		context("when a dependency checksum does not match", func() {
+			it.Before(func() {
+				content, err := os.ReadFile(filepath.Join(buildpackDir, "buildpack.toml"))
+				Expect(err).NotTo(HaveOccurred())
+
+				content = []byte(strings.ReplaceAll(string(content), checksum, fmt.Sprintf("%x", sha256.Sum256(nil))))
+				Expect(os.WriteFile(filepath.Join(buildpackDir, "buildpack.toml"), content, 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				err := packager.Execute(buildpackDir, "some-output", "some-version", true)
+				Expect(err).To(MatchError(ContainSubstring("checksum mismatch")))
+			})
+		})
+
+		context("when pack fails", func() {
+			it.Before(func() {
+				pack.ExecuteCall.Stub = nil
+				pack.ExecuteCall.Returns.Error = errors.New("some pack error")
+			})
+
+			it("returns an error", func() {
+				err := packager.Execute(buildpackDir, "some-output", "some-version", false)
+				Expect(err).To(MatchError("some pack error"))
+			})
+		})
+	})
+}
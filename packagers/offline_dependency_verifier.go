@@ -0,0 +1,342 @@
+package packagers
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+)
+
+// OfflineDependencyError lists every problem found in the dependencies of an
+// offline buildpack package.
+type OfflineDependencyError struct {
+	// Missing dependencies are listed in the expected buildpack.toml but are
+	// not included in the package.
+	Missing []cargo.ConfigMetadataDependency
+
+	// Extra files are included under dependencies/ in the package but do
+	// not belong to any expected dependency.
+	Extra []string
+
+	ChecksumMismatches []OfflineDependencyChecksumMismatch
+
+	// NotRewritten dependencies are included in the package, but the
+	// packaged buildpack.toml still points at their remote URI.
+	NotRewritten []cargo.ConfigMetadataDependency
+}
+
+type OfflineDependencyChecksumMismatch struct {
+	Dependency cargo.ConfigMetadataDependency
+	Path       string
+	Expected   string
+	Actual     string
+}
+
+func (e OfflineDependencyError) Error() string {
+	var lines []string
+	for _, dependency := range e.Missing {
+		lines = append(lines, fmt.Sprintf("  - missing %s", describeDependency(dependency)))
+	}
+
+	for _, path := range e.Extra {
+		lines = append(lines, fmt.Sprintf("  - extra file %s", path))
+	}
+
+	for _, mismatch := range e.ChecksumMismatches {
+		lines = append(lines, fmt.Sprintf("  - checksum mismatch for %s at %s: expected %s, got %s",
+			describeDependency(mismatch.Dependency), mismatch.Path, mismatch.Expected, mismatch.Actual))
+	}
+
+	for _, dependency := range e.NotRewritten {
+		lines = append(lines, fmt.Sprintf("  - uri of %s was not rewritten to a file: %s", describeDependency(dependency), dependency.URI))
+	}
+
+	return fmt.Sprintf("offline buildpack package dependencies do not match buildpack.toml:\n%s", strings.Join(lines, "\n"))
+}
+
+// OfflineDependencyVerifier checks that an offline buildpack package, as
+// produced by Jam or Libpak with the offline flag, includes every dependency
+// listed in the [[metadata.dependencies]] of a buildpack.toml.
+//
+// Each dependency must be included in the package with a matching sha256 or
+// sha512 checksum, and its URI in the packaged buildpack.toml must have been
+// rewritten to a file:// URI pointing at it. Libpak packages keep the original
+// URIs and look dependencies up by checksum under dependencies/<hash>/
+// instead, so the URI check can be disabled with WithoutURIRewrites.
+type OfflineDependencyVerifier struct {
+	rewrittenURIs bool
+}
+
+func NewOfflineDependencyVerifier() OfflineDependencyVerifier {
+	return OfflineDependencyVerifier{
+		rewrittenURIs: true,
+	}
+}
+
+func (v OfflineDependencyVerifier) WithoutURIRewrites() OfflineDependencyVerifier {
+	v.rewrittenURIs = false
+	return v
+}
+
+// Execute verifies the package at the given path, either a buildpack tarball
+// (.tgz) or a .cnb file, against the expected buildpack config. It returns an
+// OfflineDependencyError when the dependencies do not match.
+func (v OfflineDependencyVerifier) Execute(packagePath string, config cargo.Config) error {
+	// Without a checksum, a dependency could neither be found by checksum nor
+	// verified.
+	for _, expected := range config.Metadata.Dependencies {
+		if expected.Checksum == "" && expected.SHA256 == "" {
+			return fmt.Errorf("failed to verify dependency %s: it has no checksum or sha256 in buildpack.toml", describeDependency(expected))
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "offline-package")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up %s: %s\n", dir, err)
+		}
+	}()
+
+	root, err := extractBuildpackPackage(packagePath, dir)
+	if err != nil {
+		return fmt.Errorf("failed to open buildpack package %s: %w", packagePath, err)
+	}
+
+	packaged, err := cargo.NewBuildpackParser().Parse(filepath.Join(root, "buildpack.toml"))
+	if err != nil {
+		return fmt.Errorf("failed to parse packaged buildpack.toml: %w", err)
+	}
+
+	var result OfflineDependencyError
+	used := map[string]bool{}
+	for _, expected := range config.Metadata.Dependencies {
+		checksum := dependencyChecksum(expected)
+
+		index := slices.IndexFunc(packaged.Metadata.Dependencies, func(dependency cargo.ConfigMetadataDependency) bool {
+			return dependency.ID == expected.ID &&
+				dependency.Version == expected.Version &&
+				dependency.OS == expected.OS &&
+				dependency.Arch == expected.Arch &&
+				slices.Equal(dependency.Stacks, expected.Stacks)
+		})
+		if index < 0 {
+			result.Missing = append(result.Missing, expected)
+			continue
+		}
+		dependency := packaged.Metadata.Dependencies[index]
+
+		var path string
+		if strings.HasPrefix(dependency.URI, "file://") {
+			path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(strings.TrimPrefix(dependency.URI, "file://"))), "/")
+			if !filepath.IsLocal(path) {
+				return fmt.Errorf("failed to verify dependency %s: %s points outside of the package", describeDependency(expected), dependency.URI)
+			}
+		} else {
+			if v.rewrittenURIs {
+				result.NotRewritten = append(result.NotRewritten, dependency)
+			}
+
+			matches, err := filepath.Glob(filepath.Join(root, "dependencies", checksum.Hash(), "*"))
+			if err != nil {
+				return fmt.Errorf("failed to find dependency %s: %w", describeDependency(expected), err)
+			}
+
+			if len(matches) == 0 {
+				result.Missing = append(result.Missing, expected)
+				continue
+			}
+
+			path, err = filepath.Rel(root, matches[0])
+			if err != nil {
+				return err
+			}
+			path = filepath.ToSlash(path)
+		}
+
+		actual, err := fileChecksum(root, path, checksum.Algorithm())
+		if err != nil {
+			if os.IsNotExist(err) {
+				result.Missing = append(result.Missing, expected)
+				continue
+			}
+
+			return fmt.Errorf("failed to checksum dependency %s: %w", describeDependency(expected), err)
+		}
+
+		used[path] = true
+		if actual != checksum.Hash() {
+			result.ChecksumMismatches = append(result.ChecksumMismatches, OfflineDependencyChecksumMismatch{
+				Dependency: expected,
+				Path:       path,
+				Expected:   checksum.Hash(),
+				Actual:     actual,
+			})
+		}
+	}
+
+	err = filepath.Walk(filepath.Join(root, "dependencies"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		// Libpak writes the metadata of each dependency next to it as
+		// dependencies/<sha256>.toml.
+		if filepath.Dir(rel) == "dependencies" && filepath.Ext(rel) == ".toml" {
+			return nil
+		}
+
+		if !used[rel] {
+			result.Extra = append(result.Extra, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list packaged dependencies: %w", err)
+	}
+	sort.Strings(result.Extra)
+
+	if len(result.Missing) > 0 || len(result.Extra) > 0 || len(result.ChecksumMismatches) > 0 || len(result.NotRewritten) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// extractBuildpackPackage extracts a buildpack tarball or .cnb file into the
+// given directory and returns the directory containing its buildpack.toml.
+func extractBuildpackPackage(path, dir string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	archiveDir := filepath.Join(dir, "archive")
+	err = vacation.NewArchive(file).Decompress(archiveDir)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(archiveDir, "oci-layout")); err != nil {
+		return archiveDir, nil
+	}
+
+	image, metadata, err := openBuildpackage(archiveDir)
+	if err != nil {
+		return "", err
+	}
+
+	diffID, err := v1.NewHash(metadata.layers[metadata.ID][metadata.Version].LayerDiffID)
+	if err != nil {
+		return "", fmt.Errorf("failed to find layer of %s@%s: %w", metadata.ID, metadata.Version, err)
+	}
+
+	layer, err := image.LayerByDiffID(diffID)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := layer.Uncompressed()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := content.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close layer %s: %s\n", diffID, err)
+		}
+	}()
+
+	layerDir := filepath.Join(dir, "layer")
+	err = vacation.NewTarArchive(content).Decompress(layerDir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(layerDir, "cnb", "buildpacks", strings.ReplaceAll(metadata.ID, "/", "_"), metadata.Version), nil
+}
+
+// dependencyChecksum returns the checksum of a dependency as algorithm:hash,
+// falling back to the deprecated sha256 field.
+func dependencyChecksum(dependency cargo.ConfigMetadataDependency) cargo.Checksum {
+	if dependency.Checksum != "" {
+		return cargo.Checksum(dependency.Checksum)
+	}
+
+	return cargo.Checksum("sha256:" + dependency.SHA256)
+}
+
+func describeDependency(dependency cargo.ConfigMetadataDependency) string {
+	description := fmt.Sprintf("%s@%s", dependency.ID, dependency.Version)
+	if len(dependency.Stacks) > 0 {
+		description = fmt.Sprintf("%s (stacks: %s)", description, strings.Join(dependency.Stacks, ", "))
+	}
+
+	if dependency.Arch != "" {
+		description = fmt.Sprintf("%s (arch: %s)", description, dependency.Arch)
+	}
+
+	return description
+}
+
+// fileChecksum returns the hex encoded checksum of the file at the given path
+// within root. Paths, or symlinks, that lead outside of root are rejected.
+func fileChecksum(root, path, algorithm string) (string, error) {
+	var hash hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "sha256":
+		hash = sha256.New()
+	case "sha512":
+		hash = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm %q: the following algorithms are supported [sha256, sha512]", algorithm)
+	}
+
+	file, err := os.OpenInRoot(root, path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
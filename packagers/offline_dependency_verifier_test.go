@@ -0,0 +1,371 @@
+package packagers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testOfflineDependencyVerifier(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		nodeChecksum   string
+		pythonChecksum string
+
+		config   cargo.Config
+		verifier packagers.OfflineDependencyVerifier
+		path     string
+	)
+
+	it.Before(func() {
+		nodeChecksum = fmt.Sprintf("%x", sha256.Sum256([]byte("node")))
+		pythonChecksum = fmt.Sprintf("%x", sha256.Sum256([]byte("python")))
+
+		config = cargo.Config{
+			API: "0.7",
+			Buildpack: cargo.ConfigBuildpack{
+				ID:      "some-org/some-buildpack",
+				Version: "1.2.3",
+			},
+			Metadata: cargo.ConfigMetadata{
+				Dependencies: []cargo.ConfigMetadataDependency{
+					{
+						ID:       "node",
+						Version:  "20.1.0",
+						Checksum: "sha256:" + nodeChecksum,
+						URI:      "https://example.com/node.tgz",
+						Stacks:   []string{"some-stack"},
+					},
+					{
+						ID:      "python",
+						Version: "3.12.0",
+						SHA256:  pythonChecksum,
+						URI:     "https://example.com/python.tgz",
+						Stacks:  []string{"some-stack"},
+					},
+				},
+			},
+		}
+
+		verifier = packagers.NewOfflineDependencyVerifier()
+		path = filepath.Join(t.TempDir(), "package")
+	})
+
+	packaged := func(files map[string]string) map[string]string {
+		packagedConfig := config
+		packagedConfig.Metadata.Dependencies = nil
+		for _, dependency := range config.Metadata.Dependencies {
+			checksum := dependency.SHA256
+			if checksum == "" {
+				_, checksum, _ = strings.Cut(dependency.Checksum, ":")
+			}
+			dependency.URI = fmt.Sprintf("file:///dependencies/%s/%s.tgz", checksum, dependency.ID)
+			packagedConfig.Metadata.Dependencies = append(packagedConfig.Metadata.Dependencies, dependency)
+		}
+
+		buffer := bytes.NewBuffer(nil)
+		Expect(cargo.EncodeConfig(buffer, packagedConfig)).To(Succeed())
+
+		result := map[string]string{"buildpack.toml": buffer.String()}
+		for name, content := range files {
+			result[name] = content
+		}
+
+		return result
+	}
+
+	context("when given a jam tarball", func() {
+		it("succeeds when every dependency is included", func() {
+			Expect(writeTarball(path, true, packaged(map[string]string{
+				fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum):     "node",
+				fmt.Sprintf("dependencies/%s/python.tgz", pythonChecksum): "python",
+			}))).To(Succeed())
+
+			Expect(verifier.Execute(path, config)).To(Succeed())
+		})
+
+		it("reports missing, extra and mismatched dependencies", func() {
+			Expect(writeTarball(path, true, packaged(map[string]string{
+				fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum): "not-node",
+				"dependencies/some-checksum/ruby.tgz":                 "ruby",
+			}))).To(Succeed())
+
+			err := verifier.Execute(path, config)
+			Expect(err).To(MatchError(packagers.OfflineDependencyError{
+				Missing: []cargo.ConfigMetadataDependency{config.Metadata.Dependencies[1]},
+				Extra:   []string{"dependencies/some-checksum/ruby.tgz"},
+				ChecksumMismatches: []packagers.OfflineDependencyChecksumMismatch{
+					{
+						Dependency: config.Metadata.Dependencies[0],
+						Path:       fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum),
+						Expected:   nodeChecksum,
+						Actual:     fmt.Sprintf("%x", sha256.Sum256([]byte("not-node"))),
+					},
+				},
+			}))
+			Expect(err.Error()).To(ContainSubstring("missing python@3.12.0 (stacks: some-stack)"))
+			Expect(err.Error()).To(ContainSubstring("extra file dependencies/some-checksum/ruby.tgz"))
+		})
+
+		context("when a dependency has a sha512 checksum", func() {
+			var nodeSHA512 string
+
+			it.Before(func() {
+				nodeSHA512 = fmt.Sprintf("%x", sha512.Sum512([]byte("node")))
+				config.Metadata.Dependencies[0].Checksum = "sha512:" + nodeSHA512
+			})
+
+			it("verifies it with sha512", func() {
+				Expect(writeTarball(path, true, packaged(map[string]string{
+					fmt.Sprintf("dependencies/%s/node.tgz", nodeSHA512):       "node",
+					fmt.Sprintf("dependencies/%s/python.tgz", pythonChecksum): "python",
+				}))).To(Succeed())
+
+				Expect(verifier.Execute(path, config)).To(Succeed())
+			})
+		})
+
+		context("when the dependency URIs were not rewritten", func() {
+			it.Before(func() {
+				buffer := bytes.NewBuffer(nil)
+				Expect(cargo.EncodeConfig(buffer, config)).To(Succeed())
+
+				Expect(writeTarball(path, true, map[string]string{
+					"buildpack.toml": buffer.String(),
+					fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum):     "node",
+					fmt.Sprintf("dependencies/%s.toml", nodeChecksum):         "",
+					fmt.Sprintf("dependencies/%s/python.tgz", pythonChecksum): "python",
+				})).To(Succeed())
+			})
+
+			it("reports them", func() {
+				var offlineErr packagers.OfflineDependencyError
+				Expect(errors.As(verifier.Execute(path, config), &offlineErr)).To(BeTrue())
+				Expect(offlineErr.NotRewritten).To(Equal(config.Metadata.Dependencies))
+				Expect(offlineErr.Missing).To(BeEmpty())
+				Expect(offlineErr.Extra).To(BeEmpty())
+			})
+
+			context("when URI rewrites are not required", func() {
+				it("finds the dependencies by checksum", func() {
+					Expect(verifier.WithoutURIRewrites().Execute(path, config)).To(Succeed())
+				})
+			})
+		})
+	})
+
+	context("when given a .cnb file", func() {
+		it.Before(func() {
+			files := map[string]string{}
+			for name, content := range packaged(map[string]string{
+				fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum): "node",
+			}) {
+				files[filepath.Join("cnb", "buildpacks", "some-org_some-buildpack", "1.2.3", name)] = content
+			}
+
+			Expect(writeCNB(path, "some-org/some-buildpack", "1.2.3", files)).To(Succeed())
+		})
+
+		it("verifies the root buildpack layer", func() {
+			err := verifier.Execute(path, config)
+			Expect(err).To(MatchError(packagers.OfflineDependencyError{
+				Missing: []cargo.ConfigMetadataDependency{config.Metadata.Dependencies[1]},
+			}))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the package does not exist", func() {
+			it("returns an error", func() {
+				err := verifier.Execute("/no/such/package", config)
+				Expect(err).To(MatchError(ContainSubstring("failed to open buildpack package /no/such/package")))
+			})
+		})
+
+		context("when a dependency has a checksum with an unsupported algorithm", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[0].Checksum = "md5:" + nodeChecksum
+			})
+
+			it("returns an error", func() {
+				Expect(writeTarball(path, true, packaged(map[string]string{
+					fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum):     "node",
+					fmt.Sprintf("dependencies/%s/python.tgz", pythonChecksum): "python",
+				}))).To(Succeed())
+
+				err := verifier.Execute(path, config)
+				Expect(err).To(MatchError(ContainSubstring(`failed to checksum dependency node@20.1.0 (stacks: some-stack): unsupported checksum algorithm "md5"`)))
+			})
+		})
+
+		context("when a dependency has no checksum", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[1].SHA256 = ""
+			})
+
+			it("returns an error", func() {
+				Expect(writeTarball(path, true, packaged(map[string]string{
+					fmt.Sprintf("dependencies/%s/node.tgz", nodeChecksum):     "node",
+					fmt.Sprintf("dependencies/%s/python.tgz", pythonChecksum): "python",
+				}))).To(Succeed())
+
+				err := verifier.Execute(path, config)
+				Expect(err).To(MatchError("failed to verify dependency python@3.12.0 (stacks: some-stack): it has no checksum or sha256 in buildpack.toml"))
+			})
+		})
+
+		context("when a dependency uri points outside of the package", func() {
+			it("returns an error", func() {
+				files := packaged(nil)
+				files["buildpack.toml"] = strings.ReplaceAll(files["buildpack.toml"], "file:///dependencies/"+nodeChecksum, "file://../../dependencies/"+nodeChecksum)
+				Expect(writeTarball(path, true, files)).To(Succeed())
+
+				err := verifier.Execute(path, config)
+				Expect(err).To(MatchError(fmt.Sprintf("failed to verify dependency node@20.1.0 (stacks: some-stack): file://../../dependencies/%s/node.tgz points outside of the package", nodeChecksum)))
+			})
+		})
+
+		context("when the package has no buildpack.toml", func() {
+			it("returns an error", func() {
+				Expect(writeTarball(path, true, map[string]string{"README.md": ""})).To(Succeed())
+
+				err := verifier.Execute(path, config)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse packaged buildpack.toml")))
+			})
+		})
+	})
+}
+
+func tarFiles(writer io.Writer, files map[string]string) error {
+	tw := tar.NewWriter(writer)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			return err
+		}
+
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeTarball(path string, compress bool, files map[string]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if !compress {
+		return tarFiles(file, files)
+	}
+
+	gw := gzip.NewWriter(file)
+	err = tarFiles(gw, files)
+	if err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func writeCNB(path, id, version string, files map[string]string) error {
+	buffer := bytes.NewBuffer(nil)
+	err := tarFiles(buffer, files)
+	if err != nil {
+		return err
+	}
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buffer.Bytes())), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return err
+	}
+
+	image, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return err
+	}
+
+	image, err = mutate.Config(image, v1.Config{
+		Labels: map[string]string{
+			"io.buildpacks.buildpackage.metadata": fmt.Sprintf(`{"id": %q, "version": %q}`, id, version),
+			"io.buildpacks.buildpack.layers":      fmt.Sprintf(`{%q: {%q: {"layerDiffID": %q}}}`, id, version, diffID),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	layoutDir, err := os.MkdirTemp("", "layout")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(layoutDir)
+
+	layoutPath, err := layout.Write(layoutDir, empty.Index)
+	if err != nil {
+		return err
+	}
+
+	err = layoutPath.AppendImage(image)
+	if err != nil {
+		return err
+	}
+
+	layoutFiles := map[string]string{}
+	err = filepath.Walk(layoutDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(layoutDir, p)
+		if err != nil {
+			return err
+		}
+
+		layoutFiles[rel] = string(content)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeTarball(path, false, layoutFiles)
+}
@@ -0,0 +1,230 @@
+package packagers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/paketo-buildpacks/occam/internal/command"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+// Result describes a buildpack packaged by one of the packagers.
+type Result struct {
+	// Path is the location of the packaged .cnb file.
+	Path string
+
+	// Digest is the sha256 digest of the packaged file.
+	Digest string
+
+	// BuildpackID and BuildpackVersion are read from the buildpackage
+	// metadata of the packaged file.
+	BuildpackID      string
+	BuildpackVersion string
+
+	// Offline is set when the dependencies of the buildpack are included in
+	// the package: the packaged buildpack.toml points them at file:// URIs, or,
+	// as libpak does, they are stored under dependencies/.
+	Offline bool
+
+	// Targets lists the package built for each target when the buildpack
+	// was packaged for several targets. Path is then a multi-platform
+	// package holding all of them.
+	Targets []TargetPackage
+
+	// Logs holds the combined output of the packaging tool and of `pack`.
+	Logs string
+}
+
+// logBuffer captures the output of the packaging tools while passing it on to
+// the configured writers. The tools write to stdout and stderr concurrently.
+type logBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (l *logBuffer) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.buffer.Write(p)
+}
+
+func (l *logBuffer) String() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.buffer.String()
+}
+
+// tee returns writers for the stdout and stderr of a tool that write to the
+// given writers and to the logs.
+func (l *logBuffer) tee(stdout, stderr io.Writer) (io.Writer, io.Writer) {
+	return io.MultiWriter(stdout, l), io.MultiWriter(stderr, l)
+}
+
+// timeout adds the output captured so far to the error of a tool that was
+// stopped because its context was done.
+func (l *logBuffer) timeout(err error) error {
+	var timeoutErr command.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	return fmt.Errorf("%w\n\nOutput:\n%s", err, l)
+}
+
+type buildpackageMetadata struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+
+	layers map[string]map[string]struct {
+		LayerDiffID string `json:"layerDiffID"`
+	}
+}
+
+// newResult describes the .cnb file written to the given output path.
+func newResult(output string, logs string) (Result, error) {
+	result, err := ReadResult(output)
+	result.Logs = logs
+
+	return result, err
+}
+
+// ReadResult describes a .cnb file that was packaged earlier, such as one
+// served from the freezer cache. Its Logs are empty.
+func ReadResult(output string) (Result, error) {
+	result := Result{Path: output}
+
+	digest, err := fileChecksum(filepath.Dir(output), filepath.Base(output), "sha256")
+	if err != nil {
+		return result, fmt.Errorf("failed to read packaged buildpack %s: %w", output, err)
+	}
+	result.Digest = fmt.Sprintf("sha256:%s", digest)
+
+	dir, err := os.MkdirTemp("", "packaged-buildpack")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up %s: %s\n", dir, err)
+		}
+	}()
+
+	root, err := extractBuildpackPackage(output, dir)
+	if err != nil {
+		return result, fmt.Errorf("failed to read packaged buildpack %s: %w", output, err)
+	}
+
+	_, metadata, err := openBuildpackage(filepath.Join(dir, "archive"))
+	if err != nil {
+		return result, fmt.Errorf("failed to read packaged buildpack %s: %w", output, err)
+	}
+
+	result.BuildpackID = metadata.ID
+	result.BuildpackVersion = metadata.Version
+
+	result.Offline, err = includesDependencies(root)
+	if err != nil {
+		return result, fmt.Errorf("failed to read packaged buildpack %s: %w", output, err)
+	}
+
+	return result, nil
+}
+
+// includesDependencies reports whether the buildpack at root includes its
+// dependencies.
+func includesDependencies(root string) (bool, error) {
+	config, err := cargo.NewBuildpackParser().Parse(filepath.Join(root, "buildpack.toml"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	for _, dependency := range config.Metadata.Dependencies {
+		if strings.HasPrefix(dependency.URI, "file://") {
+			return true, nil
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "dependencies"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	return len(entries) > 0, nil
+}
+
+// openBuildpackage opens the OCI layout of a .cnb file extracted to the given
+// directory and decodes its buildpackage labels.
+func openBuildpackage(dir string) (v1.Image, buildpackageMetadata, error) {
+	layoutPath, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, buildpackageMetadata{}, err
+	}
+
+	index, err := layoutPath.ImageIndex()
+	if err != nil {
+		return nil, buildpackageMetadata{}, err
+	}
+
+	image, err := firstLayoutImage(index)
+	if err != nil {
+		return nil, buildpackageMetadata{}, err
+	}
+
+	configFile, err := image.ConfigFile()
+	if err != nil {
+		return nil, buildpackageMetadata{}, err
+	}
+
+	var metadata buildpackageMetadata
+	err = json.Unmarshal([]byte(configFile.Config.Labels["io.buildpacks.buildpackage.metadata"]), &metadata)
+	if err != nil {
+		return nil, buildpackageMetadata{}, fmt.Errorf("failed to parse buildpackage metadata label: %w", err)
+	}
+
+	err = json.Unmarshal([]byte(configFile.Config.Labels["io.buildpacks.buildpack.layers"]), &metadata.layers)
+	if err != nil {
+		return nil, buildpackageMetadata{}, fmt.Errorf("failed to parse buildpack layers label: %w", err)
+	}
+
+	return image, metadata, nil
+}
+
+// firstLayoutImage returns the first image of an index, descending into nested
+// indexes such as those of multi-platform packages.
+func firstLayoutImage(index v1.ImageIndex) (v1.Image, error) {
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(manifest.Manifests) == 0 {
+		return nil, fmt.Errorf("image index is empty")
+	}
+
+	descriptor := manifest.Manifests[0]
+	if descriptor.MediaType.IsIndex() {
+		child, err := index.ImageIndex(descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		return firstLayoutImage(child)
+	}
+
+	return index.Image(descriptor.Digest)
+}
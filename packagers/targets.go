@@ -0,0 +1,282 @@
+package packagers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+)
+
+// TargetPackage is the package built for one of several targets.
+type TargetPackage struct {
+	Target string
+	Path   string
+}
+
+// packStep runs `pack buildpack package` (or `pack extension package`) for
+// the buildpack in dir, once per target.
+//
+// With a single target, the package is written to the output path. With
+// several targets, one .cnb is written per target next to the output, named
+// <output>-<os>-<arch>[-<variant>].cnb, and the output is a multi-platform
+// .cnb holding an image index of all of them.
+//
+// When the targets were given explicitly and the buildpack was packaged with
+// its dependencies, the dependencies that do not match a target are removed
+// from the package built for it.
+type packStep struct {
+	pack    Executable
+	kind    string
+	dir     string
+	path    bool
+	targets []string
+	filter  bool
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+func defaultTargets() []string {
+	return []string{fmt.Sprintf("linux/%s", runtime.GOARCH)}
+}
+
+func (s packStep) execute(output string) ([]TargetPackage, error) {
+	targets := s.targets
+	if len(targets) == 0 {
+		targets = defaultTargets()
+	}
+
+	var platforms []v1.Platform
+	for _, target := range targets {
+		platform, err := parseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+
+		platforms = append(platforms, platform)
+	}
+
+	if len(targets) == 1 {
+		if s.filter {
+			err := filterDependencies(s.dir, platforms[0])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, s.run(s.dir, output, targets[0])
+	}
+
+	var packages []TargetPackage
+	for i, target := range targets {
+		dir := s.dir
+		if s.filter {
+			tmpDir, err := os.MkdirTemp("", "target")
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				if err := os.RemoveAll(tmpDir); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: failed to clean up %s: %s\n", tmpDir, err)
+				}
+			}()
+
+			dir = filepath.Join(tmpDir, "buildpack")
+			err = fs.Copy(s.dir, dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy buildpack for target %s: %w", target, err)
+			}
+
+			err = filterDependencies(dir, platforms[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		path := fmt.Sprintf("%s-%s.cnb", strings.TrimSuffix(output, filepath.Ext(output)), strings.ReplaceAll(target, "/", "-"))
+		err := s.run(dir, path, target)
+		if err != nil {
+			return nil, err
+		}
+
+		packages = append(packages, TargetPackage{Target: target, Path: path})
+	}
+
+	err := writeImageIndex(packages, platforms, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return packages, nil
+}
+
+func (s packStep) run(dir, output, target string) error {
+	execution := pexec.Execution{
+		Args: []string{
+			s.kind, "package",
+			output,
+		},
+		Stdout: s.stdout,
+		Stderr: s.stderr,
+	}
+
+	if s.path {
+		execution.Args = append(execution.Args, "--path", dir)
+	} else {
+		execution.Dir = dir
+	}
+
+	execution.Args = append(execution.Args,
+		"--format", "file",
+		"--target", target,
+	)
+
+	return s.pack.Execute(execution)
+}
+
+func parseTarget(target string) (v1.Platform, error) {
+	parts := strings.Split(target, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return v1.Platform{}, fmt.Errorf("invalid target %q: expected os/arch[/variant]", target)
+	}
+
+	platform := v1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+
+	return platform, nil
+}
+
+// filterDependencies removes the dependencies that do not match the target
+// from the buildpack.toml in dir, along with their vendored files.
+// Dependencies without an os or arch match every target.
+func filterDependencies(dir string, platform v1.Platform) error {
+	path := filepath.Join(dir, "buildpack.toml")
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	config, err := cargo.NewBuildpackParser().Parse(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse buildpack.toml: %w", err)
+	}
+
+	var kept, removed []cargo.ConfigMetadataDependency
+	for _, dependency := range config.Metadata.Dependencies {
+		if dependencyMatches(dependency, platform) {
+			kept = append(kept, dependency)
+		} else {
+			removed = append(removed, dependency)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	for _, dependency := range removed {
+		checksum := dependencyChecksum(dependency)
+		if slices.ContainsFunc(kept, func(d cargo.ConfigMetadataDependency) bool { return dependencyChecksum(d) == checksum }) {
+			continue
+		}
+
+		for _, name := range []string{checksum.Hash(), checksum.Hash() + ".toml"} {
+			err = os.RemoveAll(filepath.Join(dir, "dependencies", name))
+			if err != nil {
+				return fmt.Errorf("failed to remove dependency %s: %w", describeDependency(dependency), err)
+			}
+		}
+
+		config.Metadata.IncludeFiles = slices.DeleteFunc(config.Metadata.IncludeFiles, func(file string) bool {
+			return strings.HasPrefix(file, fmt.Sprintf("dependencies/%s/", checksum.Hash())) ||
+				file == fmt.Sprintf("dependencies/%s.toml", checksum.Hash())
+		})
+	}
+	config.Metadata.Dependencies = kept
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack.toml: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	err = cargo.EncodeConfig(file, config)
+	if err != nil {
+		return fmt.Errorf("failed to write buildpack.toml: %w", err)
+	}
+
+	return nil
+}
+
+func dependencyMatches(dependency cargo.ConfigMetadataDependency, platform v1.Platform) bool {
+	return (dependency.OS == "" || dependency.OS == platform.OS) &&
+		(dependency.Arch == "" || dependency.Arch == platform.Architecture)
+}
+
+// writeImageIndex writes a .cnb holding an image index of the images of the
+// given per-target packages.
+func writeImageIndex(packages []TargetPackage, platforms []v1.Platform, output string) error {
+	dir, err := os.MkdirTemp("", "image-index")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to clean up %s: %s\n", dir, err)
+		}
+	}()
+
+	index, err := layout.Write(filepath.Join(dir, "index"), empty.Index)
+	if err != nil {
+		return fmt.Errorf("failed to write image index: %w", err)
+	}
+
+	for i, pkg := range packages {
+		packageDir := filepath.Join(dir, fmt.Sprintf("package-%d", i))
+		err = extractArchive(pkg.Path, packageDir)
+		if err != nil {
+			return fmt.Errorf("failed to read package for target %s: %w", pkg.Target, err)
+		}
+
+		image, _, err := openBuildpackage(packageDir)
+		if err != nil {
+			return fmt.Errorf("failed to read package for target %s: %w", pkg.Target, err)
+		}
+
+		err = index.AppendImage(image, layout.WithPlatform(platforms[i]))
+		if err != nil {
+			return fmt.Errorf("failed to write image index: %w", err)
+		}
+	}
+
+	return writeArchive(filepath.Join(dir, "index"), output, false)
+}
+
+func extractArchive(path, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to close %s: %s\n", path, err)
+		}
+	}()
+
+	return vacation.NewArchive(file).Decompress(dir)
+}
@@ -0,0 +1,229 @@
+package packagers_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/occam/packagers"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testTargets(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+		pack       *fakes.Executable
+
+		libpakOutput string
+		output       string
+
+		packaged map[string]cargo.Config
+		vendored map[string][]string
+
+		packager packagers.Libpak
+	)
+
+	it.Before(func() {
+		libpakOutput = t.TempDir()
+		output = filepath.Join(t.TempDir(), "some-buildpack.cnb")
+
+		executable = &fakes.Executable{}
+		executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			for _, checksum := range []string{"amd64-checksum", "arm64-checksum", "noarch-checksum"} {
+				err := os.MkdirAll(filepath.Join(libpakOutput, "dependencies", checksum), os.ModePerm)
+				if err != nil {
+					return err
+				}
+
+				err = os.WriteFile(filepath.Join(libpakOutput, "dependencies", checksum+".toml"), nil, 0600)
+				if err != nil {
+					return err
+				}
+			}
+
+			return os.WriteFile(filepath.Join(libpakOutput, "buildpack.toml"), []byte(`
+api = "0.7"
+
+[buildpack]
+  id = "some-org/some-buildpack"
+  version = "some-version"
+
+[metadata]
+  include-files = [
+    "buildpack.toml",
+    "dependencies/amd64-checksum/some-dependency.tgz",
+    "dependencies/amd64-checksum.toml",
+    "dependencies/arm64-checksum/some-dependency.tgz",
+    "dependencies/arm64-checksum.toml",
+    "dependencies/noarch-checksum/other-dependency.tgz",
+    "dependencies/noarch-checksum.toml",
+  ]
+
+[[metadata.dependencies]]
+  id = "some-dependency"
+  version = "1.0.0"
+  arch = "amd64"
+  sha256 = "amd64-checksum"
+
+[[metadata.dependencies]]
+  id = "some-dependency"
+  version = "1.0.0"
+  arch = "arm64"
+  sha256 = "arm64-checksum"
+
+[[metadata.dependencies]]
+  id = "other-dependency"
+  version = "2.0.0"
+  sha256 = "noarch-checksum"
+`), 0600)
+		}
+
+		packaged = map[string]cargo.Config{}
+		vendored = map[string][]string{}
+
+		pack = &fakes.Executable{}
+		pack.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			var dir, target string
+			for i, arg := range execution.Args {
+				switch arg {
+				case "--path":
+					dir = execution.Args[i+1]
+				case "--target":
+					target = execution.Args[i+1]
+				}
+			}
+
+			config, err := cargo.NewBuildpackParser().Parse(filepath.Join(dir, "buildpack.toml"))
+			if err != nil {
+				return err
+			}
+			packaged[target] = config
+
+			entries, err := os.ReadDir(filepath.Join(dir, "dependencies"))
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				vendored[target] = append(vendored[target], entry.Name())
+			}
+
+			_, _ = fmt.Fprintf(execution.Stdout, "packaging for %s\n", target)
+			return writeCNB(execution.Args[2], "some-org/some-buildpack", "some-version", map[string]string{})
+		}
+
+		packager = packagers.NewLibpak().
+			WithExecutable(executable).
+			WithPack(pack).
+			WithTempOutput(func(string, string) (string, error) { return libpakOutput, nil }).
+			WithOutput(io.Discard)
+	})
+
+	context("when given a single target", func() {
+		it("packages for that target and filters the offline dependencies", func() {
+			err := packager.WithTargets("linux/arm64").Execute("some-buildpack-dir", output, "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pack.ExecuteCall.Receives.Execution.Args).To(Equal([]string{
+				"buildpack", "package",
+				output,
+				"--path", libpakOutput,
+				"--format", "file",
+				"--target", "linux/arm64",
+			}))
+
+			Expect(packaged["linux/arm64"].Metadata.Dependencies).To(HaveLen(2))
+			Expect(packaged["linux/arm64"].Metadata.Dependencies[0].Arch).To(Equal("arm64"))
+			Expect(packaged["linux/arm64"].Metadata.Dependencies[1].ID).To(Equal("other-dependency"))
+			Expect(vendored["linux/arm64"]).To(ConsistOf("arm64-checksum", "arm64-checksum.toml", "noarch-checksum", "noarch-checksum.toml"))
+			Expect(packaged["linux/arm64"].Metadata.IncludeFiles).To(Equal([]string{
+				"buildpack.toml",
+				"dependencies/arm64-checksum/some-dependency.tgz",
+				"dependencies/arm64-checksum.toml",
+				"dependencies/noarch-checksum/other-dependency.tgz",
+				"dependencies/noarch-checksum.toml",
+			}))
+		})
+
+		context("when packaging without dependencies", func() {
+			it("leaves the buildpack.toml untouched", func() {
+				err := packager.WithTargets("linux/arm64").Execute("some-buildpack-dir", output, "some-version", false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(packaged["linux/arm64"].Metadata.Dependencies).To(HaveLen(3))
+			})
+		})
+	})
+
+	context("when given several targets", func() {
+		it("packages each target and writes a multi-platform package", func() {
+			result, err := packager.WithTargets("linux/amd64", "linux/arm64/v8").Package("some-buildpack-dir", output, "some-version", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			amd64 := filepath.Join(filepath.Dir(output), "some-buildpack-linux-amd64.cnb")
+			arm64 := filepath.Join(filepath.Dir(output), "some-buildpack-linux-arm64-v8.cnb")
+			Expect(result.Targets).To(Equal([]packagers.TargetPackage{
+				{Target: "linux/amd64", Path: amd64},
+				{Target: "linux/arm64/v8", Path: arm64},
+			}))
+			Expect(result.BuildpackID).To(Equal("some-org/some-buildpack"))
+			Expect(result.Logs).To(Equal("packaging for linux/amd64\npackaging for linux/arm64/v8\n"))
+			Expect(amd64).To(BeARegularFile())
+			Expect(arm64).To(BeARegularFile())
+
+			Expect(vendored["linux/amd64"]).To(ConsistOf("amd64-checksum", "amd64-checksum.toml", "noarch-checksum", "noarch-checksum.toml"))
+			Expect(vendored["linux/arm64/v8"]).To(ConsistOf("arm64-checksum", "arm64-checksum.toml", "noarch-checksum", "noarch-checksum.toml"))
+
+			file, err := os.Open(output)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			dir := t.TempDir()
+			Expect(vacation.NewArchive(file).Decompress(dir)).To(Succeed())
+
+			layoutPath, err := layout.FromPath(dir)
+			Expect(err).NotTo(HaveOccurred())
+
+			index, err := layoutPath.ImageIndex()
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := index.IndexManifest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Manifests).To(HaveLen(2))
+			Expect(manifest.Manifests[0].Platform.Architecture).To(Equal("amd64"))
+			Expect(manifest.Manifests[1].Platform.Architecture).To(Equal("arm64"))
+			Expect(manifest.Manifests[1].Platform.Variant).To(Equal("v8"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when a target is invalid", func() {
+			it("returns an error", func() {
+				err := packager.WithTargets("linux").Execute("some-buildpack-dir", output, "some-version", true)
+				Expect(err).To(MatchError(`invalid target "linux": expected os/arch[/variant]`))
+			})
+		})
+
+		context("when pack does not write the package for a target", func() {
+			it.Before(func() {
+				pack.ExecuteCall.Stub = nil
+			})
+
+			it("returns an error", func() {
+				err := packager.WithTargets("linux/amd64", "linux/arm64").Execute("some-buildpack-dir", output, "some-version", false)
+				Expect(err).To(MatchError(ContainSubstring("failed to read package for target linux/amd64")))
+			})
+		})
+	})
+}
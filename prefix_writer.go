@@ -0,0 +1,55 @@
+package occam
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter writes to the underlying writer with the prefix at the start
+// of every line. It is safe to use from several goroutines, as when it gets
+// both the stdout and stderr of a command.
+type prefixWriter struct {
+	mutex     sync.Mutex
+	writer    io.Writer
+	prefix    []byte
+	lineStart bool
+}
+
+func newPrefixWriter(writer io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{
+		writer:    writer,
+		prefix:    []byte(prefix),
+		lineStart: true,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.prefix) == 0 {
+		return w.writer.Write(p)
+	}
+
+	var output []byte
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if w.lineStart {
+			output = append(output, w.prefix...)
+		}
+
+		output = append(output, line...)
+		w.lineStart = line[len(line)-1] == '\n'
+	}
+
+	_, err := w.writer.Write(output)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
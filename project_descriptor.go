@@ -0,0 +1,149 @@
+package occam
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ProjectDescriptor describes an app to be built with `pack build`. It is
+// encoded into a project.toml file, using version 0.2 of the project
+// descriptor schema.
+type ProjectDescriptor struct {
+	ID         string
+	Name       string
+	Version    string
+	Builder    string
+	Include    []string
+	Exclude    []string
+	Buildpacks []ProjectDescriptorBuildpack
+	Env        map[string]string
+}
+
+// ProjectDescriptorBuildpack identifies a buildpack to build the app with,
+// either by ID and version or by URI.
+type ProjectDescriptorBuildpack struct {
+	ID      string
+	Version string
+	URI     string
+}
+
+type projectToml struct {
+	Project    projectTomlProject    `toml:"_"`
+	Buildpacks projectTomlBuildpacks `toml:"io"`
+}
+
+type projectTomlProject struct {
+	SchemaVersion string `toml:"schema-version"`
+	ID            string `toml:"id,omitempty"`
+	Name          string `toml:"name,omitempty"`
+	Version       string `toml:"version,omitempty"`
+}
+
+type projectTomlBuildpacks struct {
+	Buildpacks projectTomlIOBuildpacks `toml:"buildpacks"`
+}
+
+type projectTomlIOBuildpacks struct {
+	Builder string                 `toml:"builder,omitempty"`
+	Include []string               `toml:"include,omitempty"`
+	Exclude []string               `toml:"exclude,omitempty"`
+	Group   []projectTomlBuildpack `toml:"group,omitempty"`
+	Build   projectTomlBuild       `toml:"build,omitempty"`
+}
+
+type projectTomlBuildpack struct {
+	ID      string `toml:"id,omitempty"`
+	Version string `toml:"version,omitempty"`
+	URI     string `toml:"uri,omitempty"`
+}
+
+type projectTomlBuild struct {
+	Env []projectTomlEnv `toml:"env,omitempty"`
+}
+
+type projectTomlEnv struct {
+	Name  string `toml:"name"`
+	Value string `toml:"value"`
+}
+
+// EncodeProjectDescriptor writes the descriptor as a project.toml.
+func EncodeProjectDescriptor(writer io.Writer, descriptor ProjectDescriptor) error {
+	if len(descriptor.Include) > 0 && len(descriptor.Exclude) > 0 {
+		return fmt.Errorf("failed to encode project descriptor: include and exclude cannot both be set")
+	}
+
+	project := projectToml{
+		Project: projectTomlProject{
+			SchemaVersion: "0.2",
+			ID:            descriptor.ID,
+			Name:          descriptor.Name,
+			Version:       descriptor.Version,
+		},
+		Buildpacks: projectTomlBuildpacks{
+			Buildpacks: projectTomlIOBuildpacks{
+				Builder: descriptor.Builder,
+				Include: descriptor.Include,
+				Exclude: descriptor.Exclude,
+			},
+		},
+	}
+
+	for _, buildpack := range descriptor.Buildpacks {
+		if buildpack.ID == "" && buildpack.URI == "" {
+			return fmt.Errorf("failed to encode project descriptor: buildpack must have an ID or a URI")
+		}
+
+		project.Buildpacks.Buildpacks.Group = append(project.Buildpacks.Buildpacks.Group, projectTomlBuildpack(buildpack))
+	}
+
+	var names []string
+	for name := range descriptor.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		project.Buildpacks.Buildpacks.Build.Env = append(project.Buildpacks.Buildpacks.Build.Env, projectTomlEnv{
+			Name:  name,
+			Value: descriptor.Env[name],
+		})
+	}
+
+	err := toml.NewEncoder(writer).Encode(project)
+	if err != nil {
+		return fmt.Errorf("failed to encode project descriptor: %w", err)
+	}
+
+	return nil
+}
+
+// WriteProjectDescriptor writes the descriptor as the project.toml of the app
+// in the given directory, usually one created with Source.
+func WriteProjectDescriptor(dir string, descriptor ProjectDescriptor) error {
+	return writeProjectDescriptor(filepath.Join(dir, "project.toml"), descriptor)
+}
+
+func writeProjectDescriptor(path string, descriptor ProjectDescriptor) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write project descriptor: %w", err)
+	}
+
+	err = EncodeProjectDescriptor(file, descriptor)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write project descriptor: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package occam_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/occam"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProjectDescriptor(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("EncodeProjectDescriptor", func() {
+		it("writes the descriptor as a project.toml", func() {
+			buffer := bytes.NewBuffer(nil)
+			err := occam.EncodeProjectDescriptor(buffer, occam.ProjectDescriptor{
+				ID:      "some-app",
+				Version: "1.2.3",
+				Builder: "paketobuildpacks/builder-jammy-base",
+				Exclude: []string{"*.md", "tests/"},
+				Buildpacks: []occam.ProjectDescriptorBuildpack{
+					{ID: "paketo-buildpacks/go-dist", Version: "2.0.0"},
+					{URI: "docker://some-buildpack"},
+				},
+				Env: map[string]string{
+					"BP_SOME_VAR":  "some-value",
+					"BP_OTHER_VAR": "other-value",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(Equal(`[_]
+  schema-version = "0.2"
+  id = "some-app"
+  version = "1.2.3"
+
+[io]
+  [io.buildpacks]
+    builder = "paketobuildpacks/builder-jammy-base"
+    exclude = ["*.md", "tests/"]
+
+    [[io.buildpacks.group]]
+      id = "paketo-buildpacks/go-dist"
+      version = "2.0.0"
+
+    [[io.buildpacks.group]]
+      uri = "docker://some-buildpack"
+    [io.buildpacks.build]
+
+      [[io.buildpacks.build.env]]
+        name = "BP_OTHER_VAR"
+        value = "other-value"
+
+      [[io.buildpacks.build.env]]
+        name = "BP_SOME_VAR"
+        value = "some-value"
+`))
+		})
+
+		context("failure cases", func() {
+			context("when both include and exclude are set", func() {
+				it("returns an error", func() {
+					err := occam.EncodeProjectDescriptor(bytes.NewBuffer(nil), occam.ProjectDescriptor{
+						Include: []string{"src/"},
+						Exclude: []string{"tests/"},
+					})
+					Expect(err).To(MatchError("failed to encode project descriptor: include and exclude cannot both be set"))
+				})
+			})
+
+			context("when a buildpack has neither an ID nor a URI", func() {
+				it("returns an error", func() {
+					err := occam.EncodeProjectDescriptor(bytes.NewBuffer(nil), occam.ProjectDescriptor{
+						Buildpacks: []occam.ProjectDescriptorBuildpack{{Version: "1.2.3"}},
+					})
+					Expect(err).To(MatchError("failed to encode project descriptor: buildpack must have an ID or a URI"))
+				})
+			})
+		})
+	})
+
+	context("WriteProjectDescriptor", func() {
+		it("writes the project.toml into the app directory", func() {
+			dir := t.TempDir()
+
+			err := occam.WriteProjectDescriptor(dir, occam.ProjectDescriptor{Include: []string{"src/"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := os.ReadFile(filepath.Join(dir, "project.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`include = ["src/"]`))
+		})
+
+		context("failure cases", func() {
+			context("when the directory does not exist", func() {
+				it("returns an error", func() {
+					err := occam.WriteProjectDescriptor(filepath.Join(t.TempDir(), "missing"), occam.ProjectDescriptor{})
+					Expect(err).To(MatchError(ContainSubstring("failed to write project descriptor")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,206 @@
+package occam
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http/httptest"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// LocalRegistry configures an OCI registry served in-process, on a random
+// localhost port, so that tests of publishing, registry buildpacks and rebase
+// do not need a real registry.
+//
+// The Docker daemon reaches the registry on localhost, but containers, such as
+// those the lifecycle runs in during `pack build --publish`, only reach it on
+// the host network: builds that publish to it must use
+// PackBuild.WithNetwork("host").
+type LocalRegistry struct {
+	daemon  DockerImageOCI
+	cleanup CleanupRegistrar
+	logs    io.Writer
+}
+
+func NewLocalRegistry() LocalRegistry {
+	return LocalRegistry{
+		logs: io.Discard,
+	}
+}
+
+// WithCleanup registers the shutdown of the registry with the given
+// registrar, usually the *testing.T of the test starting it.
+func (l LocalRegistry) WithCleanup(registrar CleanupRegistrar) LocalRegistry {
+	l.cleanup = registrar
+	return l
+}
+
+// WithDaemonClient sets the client used to read images from the Docker daemon
+// when seeding the registry with SeedFromDaemon.
+func (l LocalRegistry) WithDaemonClient(client DockerDaemonClient) LocalRegistry {
+	l.daemon = l.daemon.WithClient(client)
+	return l
+}
+
+// WithLogs sets where the requests served by the registry are logged. They
+// are discarded by default.
+func (l LocalRegistry) WithLogs(logs io.Writer) LocalRegistry {
+	l.logs = logs
+	return l
+}
+
+func (l LocalRegistry) Start() (Registry, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return Registry{}, fmt.Errorf("failed to start registry: %w", err)
+	}
+
+	server := httptest.NewUnstartedServer(registry.New(registry.Logger(log.New(l.logs, "", log.LstdFlags))))
+	server.Listener = listener
+	server.Start()
+
+	r := Registry{
+		Host:   listener.Addr().String(),
+		server: server,
+		daemon: l.daemon,
+	}
+
+	if l.cleanup != nil {
+		l.cleanup.Cleanup(r.Close)
+	}
+
+	return r, nil
+}
+
+// Registry is a running LocalRegistry. It serves plain HTTP on 127.0.0.1,
+// which the Docker daemon and pack treat as an insecure registry by default,
+// and which containers only reach on the host network.
+type Registry struct {
+	Host string
+
+	server *httptest.Server
+	daemon DockerImageOCI
+}
+
+// Ref returns the reference to the given repository, with an optional tag or
+// digest, in the registry.
+func (r Registry) Ref(repository string) string {
+	return fmt.Sprintf("%s/%s", r.Host, repository)
+}
+
+// Push pushes the image to the given repository in the registry and returns
+// its reference.
+func (r Registry) Push(repository string, image v1.Image) (string, error) {
+	ref, err := name.ParseReference(r.Ref(repository))
+	if err != nil {
+		return "", fmt.Errorf("failed to push image: %w", err)
+	}
+
+	err = remote.Write(ref, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to push image: %w", err)
+	}
+
+	return ref.String(), nil
+}
+
+// SeedFromOCILayout pushes the contents of the OCI layout at the given path
+// to the repository. A layout holding a single image is pushed as that
+// image, and a layout holding several is pushed as an image index.
+func (r Registry) SeedFromOCILayout(path, repository string) (string, error) {
+	layoutPath, err := layout.FromPath(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to seed registry: %w", err)
+	}
+
+	index, err := layoutPath.ImageIndex()
+	if err != nil {
+		return "", fmt.Errorf("failed to seed registry: %w", err)
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return "", fmt.Errorf("failed to seed registry: %w", err)
+	}
+
+	ref, err := name.ParseReference(r.Ref(repository))
+	if err != nil {
+		return "", fmt.Errorf("failed to seed registry: %w", err)
+	}
+
+	switch {
+	case len(manifest.Manifests) == 0:
+		return "", fmt.Errorf("failed to seed registry: %s holds no images", path)
+
+	case len(manifest.Manifests) == 1 && manifest.Manifests[0].MediaType.IsImage():
+		image, err := index.Image(manifest.Manifests[0].Digest)
+		if err != nil {
+			return "", fmt.Errorf("failed to seed registry: %w", err)
+		}
+
+		err = remote.Write(ref, image)
+		if err != nil {
+			return "", fmt.Errorf("failed to seed registry: %w", err)
+		}
+
+	case len(manifest.Manifests) == 1 && manifest.Manifests[0].MediaType.IsIndex():
+		child, err := index.ImageIndex(manifest.Manifests[0].Digest)
+		if err != nil {
+			return "", fmt.Errorf("failed to seed registry: %w", err)
+		}
+
+		err = remote.WriteIndex(ref, child)
+		if err != nil {
+			return "", fmt.Errorf("failed to seed registry: %w", err)
+		}
+
+	default:
+		err = remote.WriteIndex(ref, index)
+		if err != nil {
+			return "", fmt.Errorf("failed to seed registry: %w", err)
+		}
+	}
+
+	return ref.String(), nil
+}
+
+// SeedFromDaemon pushes the image from the Docker daemon to the repository.
+func (r Registry) SeedFromDaemon(image, repository string) (string, error) {
+	img, err := r.daemon.Execute(image)
+	if err != nil {
+		return "", fmt.Errorf("failed to seed registry: %w", err)
+	}
+
+	ref, err := r.Push(repository, img)
+	if err != nil {
+		return "", fmt.Errorf("failed to seed registry: %w", err)
+	}
+
+	return ref, nil
+}
+
+// Image returns the image with the given reference from the registry.
+func (r Registry) Image(ref string) (v1.Image, error) {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	image, err := remote.Image(reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	return image, nil
+}
+
+// Close shuts the registry down. Its contents are lost.
+func (r Registry) Close() {
+	r.server.Close()
+}
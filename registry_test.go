@@ -0,0 +1,198 @@
+package occam_test
+
+import (
+	"bytes"
+	ctx "context"
+	"io"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
+	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRegistry(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		daemonClient *fakes.DockerDaemonClient
+		registry     occam.Registry
+	)
+
+	it.Before(func() {
+		daemonClient = &fakes.DockerDaemonClient{}
+
+		var err error
+		registry, err = occam.NewLocalRegistry().
+			WithDaemonClient(daemonClient).
+			WithCleanup(t).
+			Start()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it("serves on a random localhost port", func() {
+		Expect(registry.Host).To(MatchRegexp(`^127\.0\.0\.1:\d+$`))
+		Expect(registry.Ref("some-app:latest")).To(Equal(registry.Host + "/some-app:latest"))
+	})
+
+	context("Push", func() {
+		it("pushes the image to the registry", func() {
+			img, err := random.Image(10, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			ref, err := registry.Push("some-app:latest", img)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(Equal(registry.Host + "/some-app:latest"))
+
+			pulled, err := registry.Image(ref)
+			Expect(err).NotTo(HaveOccurred())
+
+			expected, err := img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pulled.Digest()).To(Equal(expected))
+		})
+	})
+
+	context("SeedFromOCILayout", func() {
+		it("pushes the image of the layout", func() {
+			img, err := random.Image(10, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			path, err := layout.Write(t.TempDir(), empty.Index)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path.AppendImage(img)).To(Succeed())
+
+			ref, err := registry.SeedFromOCILayout(string(path), "some-app:latest")
+			Expect(err).NotTo(HaveOccurred())
+
+			pulled, err := registry.Image(ref)
+			Expect(err).NotTo(HaveOccurred())
+
+			expected, err := img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pulled.Digest()).To(Equal(expected))
+		})
+
+		it("pushes a layout with several images as an index", func() {
+			path, err := layout.Write(t.TempDir(), empty.Index)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i := 0; i < 2; i++ {
+				img, err := random.Image(10, 1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path.AppendImage(img)).To(Succeed())
+			}
+
+			ref, err := registry.SeedFromOCILayout(string(path), "some-app:latest")
+			Expect(err).NotTo(HaveOccurred())
+
+			reference, err := name.ParseReference(ref)
+			Expect(err).NotTo(HaveOccurred())
+
+			index, err := remote.Index(reference)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := index.IndexManifest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Manifests).To(HaveLen(2))
+		})
+
+		it("pushes a layout holding an index as that index", func() {
+			img, err := random.Image(10, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			index := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: img})
+
+			path, err := layout.Write(t.TempDir(), empty.Index)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path.AppendIndex(index)).To(Succeed())
+
+			ref, err := registry.SeedFromOCILayout(string(path), "some-app:latest")
+			Expect(err).NotTo(HaveOccurred())
+
+			reference, err := name.ParseReference(ref)
+			Expect(err).NotTo(HaveOccurred())
+
+			pushed, err := remote.Index(reference)
+			Expect(err).NotTo(HaveOccurred())
+
+			expected, err := index.Digest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pushed.Digest()).To(Equal(expected))
+		})
+
+		context("failure cases", func() {
+			context("when the layout is empty", func() {
+				it("returns an error", func() {
+					path, err := layout.Write(t.TempDir(), empty.Index)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = registry.SeedFromOCILayout(string(path), "some-app:latest")
+					Expect(err).To(MatchError(ContainSubstring("holds no images")))
+				})
+			})
+
+			context("when the path is not a layout", func() {
+				it("returns an error", func() {
+					_, err := registry.SeedFromOCILayout(t.TempDir(), "some-app:latest")
+					Expect(err).To(MatchError(ContainSubstring("failed to seed registry")))
+				})
+			})
+		})
+	})
+
+	context("SeedFromDaemon", func() {
+		it("pushes the image from the daemon", func() {
+			img, err := random.Image(10, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			digest, err := img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+
+			daemonClient.ImageInspectCall.Stub = func(_ ctx.Context, _ string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+				return client.ImageInspectResult{
+					InspectResponse: image.InspectResponse{ID: digest.String()},
+				}, nil
+			}
+			daemonClient.ImageSaveCall.Stub = func(_ ctx.Context, _ []string, _ ...client.ImageSaveOption) (client.ImageSaveResult, error) {
+				buf := bytes.NewBuffer(nil)
+				ref, _ := name.ParseReference("some-image")
+				Expect(tarball.Write(ref, img, buf)).To(Succeed())
+				return io.NopCloser(buf), nil
+			}
+
+			ref, err := registry.SeedFromDaemon("some-image", "some-app:latest")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(HavePrefix(registry.Host))
+
+			pulled, err := registry.Image(ref)
+			Expect(err).NotTo(HaveOccurred())
+
+			layers, err := pulled.Layers()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(layers).To(HaveLen(2))
+		})
+	})
+
+	context("Image", func() {
+		context("failure cases", func() {
+			context("when the image does not exist", func() {
+				it("returns an error", func() {
+					_, err := registry.Image(registry.Ref("missing:latest"))
+					Expect(err).To(MatchError(ContainSubstring("failed to fetch image")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,192 @@
+package occam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/paketo-buildpacks/occam/internal/command"
+)
+
+// retriableMessages are found in the errors, or the stderr of docker, when a
+// registry or the daemon fails in a way that is likely to be transient.
+var retriableMessages = []string{
+	"connection reset by peer",
+	"tls handshake timeout",
+	"i/o timeout",
+	"unexpected eof",
+	"toomanyrequests",
+	"408 request timeout",
+	"429 too many requests",
+	"500 internal server error",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// RetryPolicy sets how often flaky docker and registry operations are
+// attempted, and how long to wait between attempts. The zero value attempts
+// an operation once.
+type RetryPolicy struct {
+	// Attempts is the most times an operation is attempted, including the
+	// first attempt.
+	Attempts int
+
+	// Delay is the wait before the second attempt. It is multiplied by the
+	// Multiplier, or 2 when unset, before each further attempt, up to the
+	// MaxDelay when set.
+	Delay      time.Duration
+	MaxDelay   time.Duration
+	Multiplier float64
+
+	// Retriable reports whether an attempt that failed with the error is
+	// retried. It defaults to IsRetriable.
+	Retriable func(error) bool
+}
+
+// DefaultRetryPolicy attempts an operation 3 times, waiting 1s and then 2s
+// between attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:   3,
+		Delay:      time.Second,
+		MaxDelay:   30 * time.Second,
+		Multiplier: 2,
+	}
+}
+
+// do runs the operation until it succeeds, fails with an error that is not
+// retriable, or has been attempted as often as the policy allows. When it
+// was attempted more than once, the error is a RetryError. The wait between
+// attempts is cut short when the context, which may be nil, is done.
+func (p RetryPolicy) do(ctx context.Context, operation func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	retriable := p.Retriable
+	if retriable == nil {
+		retriable = IsRetriable
+	}
+
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := p.Delay
+	var attempts []error
+	for {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+
+		attempts = append(attempts, err)
+		if len(attempts) >= p.Attempts || !retriable(err) {
+			break
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: stopped retrying: %w", retryResult(attempts), ctx.Err())
+		case <-timer.C:
+		}
+
+		delay = time.Duration(float64(delay) * multiplier)
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+
+	return retryResult(attempts)
+}
+
+func retryResult(attempts []error) error {
+	if len(attempts) == 1 {
+		return attempts[0]
+	}
+
+	return RetryError{Attempts: attempts}
+}
+
+// IsRetriable reports whether an operation that failed with the error is
+// likely to succeed when attempted again: a registry that answered with a 5xx
+// or 429 status, a TLS handshake or network timeout, or a connection that was
+// reset. Commands that could not be started, were killed, or were stopped by
+// their context are never retried.
+func IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var timeoutErr command.TimeoutError
+	if errors.As(err, &timeoutErr) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, exec.ErrNotFound) {
+		return false
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		// The command was killed by a signal, or could not be run.
+		case -1, 126, 127:
+			return false
+		}
+	}
+
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		return transportErr.Temporary()
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, retriable := range retriableMessages {
+		if strings.Contains(message, retriable) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryError is returned by an operation that failed after being attempted
+// more than once. It holds the error of every attempt, which can be matched
+// with errors.Is and errors.As.
+type RetryError struct {
+	Attempts []error
+}
+
+func (e RetryError) Error() string {
+	var messages []string
+	for i, err := range e.Attempts {
+		messages = append(messages, fmt.Sprintf("  attempt %d: %s", i+1, err))
+	}
+
+	return fmt.Sprintf("failed after %d attempts:\n%s", len(e.Attempts), strings.Join(messages, "\n"))
+}
+
+func (e RetryError) Unwrap() []error {
+	return e.Attempts
+}
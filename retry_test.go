@@ -0,0 +1,178 @@
+package occam_test
+
+import (
+	ctx "context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-buildpacks/occam/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRetry(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("IsRetriable", func() {
+		it("retries transient registry and network errors", func() {
+			for _, err := range []error{
+				errors.New("exit status 1: Error response from daemon: received unexpected HTTP status: 500 Internal Server Error"),
+				errors.New("exit status 1: Error response from daemon: Get \"https://registry/v2/\": net/http: TLS handshake timeout"),
+				errors.New("exit status 1: read tcp 127.0.0.1:1234->127.0.0.1:5678: read: connection reset by peer"),
+				errors.New("exit status 1: toomanyrequests: You have reached your pull rate limit"),
+				errors.New("unexpected response status: 504 Gateway Timeout"),
+				&transport.Error{StatusCode: http.StatusBadGateway},
+				fmt.Errorf("failed to read layer: %w", syscall.ECONNRESET),
+				fmt.Errorf("failed to read layer: %w", io.ErrUnexpectedEOF),
+			} {
+				Expect(occam.IsRetriable(err)).To(BeTrue(), err.Error())
+			}
+		})
+
+		it("does not retry permanent errors", func() {
+			for _, err := range []error{
+				nil,
+				errors.New("exit status 1: Error response from daemon: manifest unknown"),
+				errors.New("unexpected response status: 404 Not Found"),
+				&transport.Error{StatusCode: http.StatusUnauthorized},
+				&exec.Error{Name: "docker", Err: exec.ErrNotFound},
+				ctx.Canceled,
+			} {
+				Expect(occam.IsRetriable(err)).To(BeFalse(), fmt.Sprint(err))
+			}
+		})
+
+		it("does not retry commands that could not be run or were killed", func() {
+			for _, script := range []string{"exit 127", "kill -9 $$"} {
+				err := exec.Command("sh", "-c", script).Run()
+				Expect(err).To(HaveOccurred())
+				Expect(occam.IsRetriable(fmt.Errorf("%w: connection reset by peer", err))).To(BeFalse(), script)
+			}
+		})
+
+		it("does not retry commands stopped by their context", func() {
+			executable := &fakes.Executable{}
+			executable.ExecuteCall.Returns.Error = errors.New("signal: killed")
+
+			c, cancel := ctx.WithCancel(ctx.Background())
+			cancel()
+
+			err := occam.NewDocker().
+				WithExecutable(executable).
+				WithContext(c).
+				WithRetryPolicy(occam.RetryPolicy{Attempts: 3}).
+				Pull.Execute("some-image")
+			Expect(err).To(MatchError(ContainSubstring("was cancelled")))
+			Expect(occam.IsRetriable(err)).To(BeFalse())
+			Expect(executable.ExecuteCall.CallCount).To(Equal(0))
+		})
+	})
+
+	context("RetryPolicy", func() {
+		var executable *fakes.Executable
+
+		it.Before(func() {
+			executable = &fakes.Executable{}
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				_, _ = fmt.Fprintln(execution.Stderr, "connection reset by peer")
+				return errors.New("exit status 1")
+			}
+		})
+
+		it("has a default policy", func() {
+			Expect(occam.DefaultRetryPolicy()).To(Equal(occam.RetryPolicy{
+				Attempts:   3,
+				Delay:      time.Second,
+				MaxDelay:   30 * time.Second,
+				Multiplier: 2,
+			}))
+		})
+
+		it("attempts an operation once by default", func() {
+			err := occam.NewDocker().WithExecutable(executable).Pull.Execute("some-image")
+			Expect(err).To(MatchError("failed to pull docker image: exit status 1: connection reset by peer"))
+			Expect(executable.ExecuteCall.CallCount).To(Equal(1))
+		})
+
+		it("waits longer before each attempt, up to the max delay", func() {
+			var times []time.Time
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				times = append(times, time.Now())
+				_, _ = fmt.Fprintln(execution.Stderr, "connection reset by peer")
+				return errors.New("exit status 1")
+			}
+
+			err := occam.NewDocker().
+				WithExecutable(executable).
+				WithRetryPolicy(occam.RetryPolicy{
+					Attempts:   4,
+					Delay:      20 * time.Millisecond,
+					MaxDelay:   50 * time.Millisecond,
+					Multiplier: 3,
+				}).
+				Pull.Execute("some-image")
+			Expect(err).To(MatchError(ContainSubstring("failed after 4 attempts")))
+			Expect(times).To(HaveLen(4))
+			Expect(times[1].Sub(times[0])).To(BeNumerically(">=", 20*time.Millisecond))
+			Expect(times[2].Sub(times[1])).To(BeNumerically(">=", 50*time.Millisecond))
+			Expect(times[3].Sub(times[2])).To(BeNumerically(">=", 50*time.Millisecond))
+		})
+
+		it("stops waiting to retry when the context is done", func() {
+			c, cancel := ctx.WithTimeout(ctx.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := occam.NewDocker().
+				WithExecutable(executable).
+				WithRetryPolicy(occam.RetryPolicy{Attempts: 3, Delay: time.Hour}).
+				WithContext(c).
+				Pull.Execute("some-image")
+			Expect(err).To(MatchError("failed to pull docker image: exit status 1: connection reset by peer: stopped retrying: context deadline exceeded"))
+			Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Minute))
+			Expect(executable.ExecuteCall.CallCount).To(Equal(1))
+		})
+
+		it("uses the given classification", func() {
+			err := occam.NewDocker().
+				WithExecutable(executable).
+				WithRetryPolicy(occam.RetryPolicy{
+					Attempts:  2,
+					Retriable: func(error) bool { return false },
+				}).
+				Pull.Execute("some-image")
+			Expect(err).To(MatchError("failed to pull docker image: exit status 1: connection reset by peer"))
+			Expect(executable.ExecuteCall.CallCount).To(Equal(1))
+		})
+
+		it("matches the error of every attempt", func() {
+			attempt := 0
+			errs := []error{&transport.Error{StatusCode: http.StatusServiceUnavailable}, errors.New("some permanent error")}
+			executable.ExecuteCall.Stub = func(pexec.Execution) error {
+				attempt++
+				return errs[attempt-1]
+			}
+
+			err := occam.NewDocker().
+				WithExecutable(executable).
+				WithRetryPolicy(occam.RetryPolicy{Attempts: 3}).
+				Pull.Execute("some-image")
+			Expect(err).To(MatchError(ContainSubstring("failed after 2 attempts")))
+
+			var transportErr *transport.Error
+			Expect(errors.As(err, &transportErr)).To(BeTrue())
+			Expect(transportErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+}
@@ -2,15 +2,17 @@ package occam
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 
+	"github.com/paketo-buildpacks/occam/internal/command"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
 )
 
 func NewVenom() Venom {
 	return Venom{
-		executable: pexec.NewExecutable("venom"),
+		executable: command.New("venom"),
 		vars:       map[string]string{},
 	}
 
@@ -20,6 +22,7 @@ type Venom struct {
 	executable Executable
 	vars       map[string]string
 	verbose    bool
+	ctx        context.Context
 }
 
 func (v Venom) WithExecutable(executable Executable) Venom {
@@ -27,6 +30,14 @@ func (v Venom) WithExecutable(executable Executable) Venom {
 	return v
 }
 
+// WithContext stops venom when the context is done. It is killed, and Execute
+// fails with an error saying it timed out, followed by the output venom wrote
+// until then.
+func (v Venom) WithContext(ctx context.Context) Venom {
+	v.ctx = ctx
+	return v
+}
+
 func (v Venom) WithVerbose() Venom {
 	v.verbose = true
 	return v
@@ -68,7 +79,7 @@ func (v Venom) Execute(venomPath string) (string, error) {
 	args = append(args, venomPath)
 
 	log := bytes.NewBuffer(nil)
-	err := v.executable.Execute(pexec.Execution{
+	err := command.WithContext(v.ctx, "venom", v.executable).Execute(pexec.Execution{
 		Args:   args,
 		Stdout: log,
 		Stderr: log,
@@ -1,9 +1,11 @@
 package occam_test
 
 import (
+	ctx "context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/occam"
@@ -104,5 +106,23 @@ func testVenom(t *testing.T, context spec.G, it spec.S) {
 			})
 
 		})
+
+		context("WithContext", func() {
+			it("returns a timeout error with the output so far", func() {
+				c, cancel := ctx.WithTimeout(ctx.Background(), 10*time.Millisecond)
+				defer cancel()
+
+				executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					_, _ = fmt.Fprintln(execution.Stdout, "some partial output")
+					<-c.Done()
+					return errors.New("signal: killed")
+				}
+
+				logs, err := venom.WithContext(c).Execute("test.yaml")
+				Expect(err).To(MatchError("failed to run venom: command `venom run test.yaml` timed out: context deadline exceeded\n\nOutput:\nsome partial output\n"))
+				Expect(errors.Is(err, ctx.DeadlineExceeded)).To(BeTrue())
+				Expect(logs).To(Equal("some partial output\n"))
+			})
+		})
 	})
 }